@@ -0,0 +1,74 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agent abstracts the Windows Update Agent operations used by Cabbie.
+package agent
+
+import (
+	"github.com/google/cabbie/updatehistory"
+	"github.com/google/cabbie/updates"
+)
+
+// Operation result codes returned by the Windows Update Agent.
+// See https://learn.microsoft.com/en-us/windows/win32/api/wuapi/ne-wuapi-operationresultcode
+const (
+	// NotStarted indicates the operation is not started.
+	NotStarted = iota
+	// InProgress indicates the operation is in progress.
+	InProgress
+	// Succeeded indicates the operation was completed successfully.
+	Succeeded
+	// SucceededWithErrors indicates the operation is complete, but one or more errors occurred.
+	SucceededWithErrors
+	// Failed indicates the operation failed to complete.
+	Failed
+	// Aborted indicates the operation is canceled.
+	Aborted
+)
+
+// Result describes the outcome of installing or uninstalling an update.
+type Result struct {
+	ResultCode     int
+	HResult        string
+	RebootRequired bool
+}
+
+// Agent is an update session able to search, download, install, uninstall,
+// hide and report on updates. Updates returned by an Agent remain valid until
+// the Agent is closed.
+type Agent interface {
+	// Search returns the updates matching the Windows Update search criteria.
+	Search(criteria string) ([]*updates.Update, error)
+	// SearchHResult returns the HResult of the most recent search.
+	SearchHResult() string
+	// Download downloads a single update and returns the operation result code.
+	Download(u *updates.Update) (int, error)
+	// Install installs a single update. If commit is true, staged changes are
+	// finalized after the install, as required for in-place upgrades.
+	Install(u *updates.Update, commit bool) (*Result, error)
+	// Uninstall removes a single installed update.
+	Uninstall(u *updates.Update) (*Result, error)
+	// AcceptEula accepts the license terms associated with an update.
+	AcceptEula(u *updates.Update) error
+	// Hide hides an update from future search results.
+	Hide(u *updates.Update) error
+	// UnHide makes a hidden update available in future search results.
+	UnHide(u *updates.Update) error
+	// History returns the recorded update history entries matching f, most recent first.
+	History(f updatehistory.Filter) ([]*updatehistory.Entry, error)
+	// RebootRequired indicates whether a system restart is pending.
+	RebootRequired() (bool, error)
+	// Close releases all resources held by the session.
+	Close()
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package agent
+
+import (
+	"fmt"
+
+	"github.com/google/cabbie/cablib"
+	"github.com/google/cabbie/download"
+	"github.com/google/cabbie/install"
+	"github.com/google/cabbie/search"
+	"github.com/google/cabbie/session"
+	"github.com/google/cabbie/updatecollection"
+	"github.com/google/cabbie/updatehistory"
+	"github.com/google/cabbie/updates"
+)
+
+// WUA is an Agent backed by the Windows Update Agent COM API.
+type WUA struct {
+	session       *session.UpdateSession
+	servers       []string
+	thirdParty    uint64
+	searchHResult string
+	collections   []*updatecollection.Collection
+}
+
+// NewWUA starts a Windows Update session that searches the given WSUS
+// servers, or third party services if thirdParty is 1.
+func NewWUA(servers []string, thirdParty uint64) (*WUA, error) {
+	s, err := session.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new Windows Update session: %v", err)
+	}
+	return &WUA{session: s, servers: servers, thirdParty: thirdParty}, nil
+}
+
+// Search returns the updates matching the Windows Update search criteria.
+func (w *WUA) Search(criteria string) ([]*updates.Update, error) {
+	q, err := search.NewSearcher(w.session, criteria, w.servers, w.thirdParty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new searcher object: %v", err)
+	}
+	defer q.Close()
+
+	uc, err := q.QueryUpdates()
+	w.searchHResult = q.SearchHResult
+	if err != nil {
+		return nil, err
+	}
+	w.collections = append(w.collections, uc)
+	return uc.Updates, nil
+}
+
+// SearchHResult returns the HResult of the most recent search.
+func (w *WUA) SearchHResult() string {
+	return w.searchHResult
+}
+
+// collection wraps a single update in a new update collection.
+func collection(u *updates.Update) (*updatecollection.Collection, error) {
+	c, err := updatecollection.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection: %v", err)
+	}
+	if err := c.Add(u.Item); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Download downloads a single update and returns the operation result code.
+func (w *WUA) Download(u *updates.Update) (int, error) {
+	c, err := collection(u)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	d, err := download.NewDownloader(w.session, c)
+	if err != nil {
+		return 0, fmt.Errorf("error creating downloader:\n %v", err)
+	}
+	defer d.Close()
+
+	if err := d.Download(); err != nil {
+		return 0, fmt.Errorf("error downloading updates:\n %v", err)
+	}
+
+	return d.ResultCode()
+}
+
+func result(inst *install.Installer) (*Result, error) {
+	rc, err := inst.ResultCode()
+	if err != nil {
+		return nil, fmt.Errorf("error getting ResultCode:\n %v", err)
+	}
+
+	hr, err := inst.HResult()
+	if err != nil {
+		return nil, fmt.Errorf("error getting ReturnCode:\n %v", err)
+	}
+
+	rb, err := inst.RebootRequired()
+	if err != nil {
+		return nil, fmt.Errorf("error getting RebootRequired:\n %v", err)
+	}
+
+	return &Result{ResultCode: rc, HResult: hr, RebootRequired: rb}, nil
+}
+
+// Install installs a single update, committing it afterwards if requested.
+func (w *WUA) Install(u *updates.Update, commit bool) (*Result, error) {
+	c, err := collection(u)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	inst, err := install.NewInstaller(w.session, c)
+	if err != nil {
+		return nil, fmt.Errorf("error creating installer: \n %v", err)
+	}
+	defer inst.Close()
+
+	if err := inst.Install(); err != nil {
+		return nil, fmt.Errorf("error installing updates:\n %v", err)
+	}
+
+	r, err := result(inst)
+	if err != nil {
+		return nil, err
+	}
+
+	if commit {
+		if err := inst.Commit(); err != nil {
+			return nil, fmt.Errorf("error committing updates:\n %v", err)
+		}
+	}
+	return r, nil
+}
+
+// Uninstall removes a single installed update.
+func (w *WUA) Uninstall(u *updates.Update) (*Result, error) {
+	c, err := collection(u)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	inst, err := install.NewInstaller(w.session, c)
+	if err != nil {
+		return nil, fmt.Errorf("error creating installer: \n %v", err)
+	}
+	defer inst.Close()
+
+	if err := inst.Uninstall(); err != nil {
+		return nil, fmt.Errorf("error uninstalling updates:\n %v", err)
+	}
+	return result(inst)
+}
+
+// AcceptEula accepts the license terms associated with an update.
+func (w *WUA) AcceptEula(u *updates.Update) error {
+	return u.AcceptEula()
+}
+
+// Hide hides an update from future search results.
+func (w *WUA) Hide(u *updates.Update) error {
+	return u.Hide()
+}
+
+// UnHide makes a hidden update available in future search results.
+func (w *WUA) UnHide(u *updates.Update) error {
+	return u.UnHide()
+}
+
+// History returns the recorded update history entries matching f, most recent first.
+func (w *WUA) History(f updatehistory.Filter) ([]*updatehistory.Entry, error) {
+	q, err := search.NewSearcher(w.session, "", w.servers, w.thirdParty)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	return updatehistory.Get(q, f)
+}
+
+// RebootRequired indicates whether a system restart is pending.
+func (w *WUA) RebootRequired() (bool, error) {
+	return cablib.RebootRequired()
+}
+
+// Close releases the session and every collection returned from it.
+func (w *WUA) Close() {
+	for _, c := range w.collections {
+		c.Close()
+	}
+	w.session.Close()
+}
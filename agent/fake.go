@@ -0,0 +1,335 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cabbie/errors"
+	"github.com/google/cabbie/updatehistory"
+	"github.com/google/cabbie/updates"
+)
+
+var (
+	orSplit  = regexp.MustCompile(`(?i)\s+or\s+`)
+	andSplit = regexp.MustCompile(`(?i)\s+and\s+`)
+	contains = regexp.MustCompile(`(?i)^(\w+)\s+contains\s+'([^']*)'$`)
+	equals   = regexp.MustCompile(`^(\w+)\s*=\s*'?([^']*)'?$`)
+)
+
+// Outcome scripts the result of operating on a single update.
+type Outcome struct {
+	// DownloadResultCode is returned by Download.
+	DownloadResultCode int
+	// Install is returned by Install.
+	Install Result
+	// Uninstall is returned by Uninstall.
+	Uninstall Result
+	// HResult is recorded in the history entry of an install or uninstall.
+	HResult errors.UpdateError
+	// Err, if set, is returned by every operation on the update.
+	Err error
+}
+
+// Success is the Outcome used for updates without a scripted Outcome.
+var Success = Outcome{
+	DownloadResultCode: Succeeded,
+	Install:            Result{ResultCode: Succeeded, HResult: errors.SUCCESS.String()},
+	Uninstall:          Result{ResultCode: Succeeded, HResult: errors.SUCCESS.String()},
+}
+
+// Fake is an in-memory Agent driven by scenario data. It never talks to the
+// Windows Update Agent and is safe to use on any platform.
+type Fake struct {
+	// Updates is the catalog that searches are evaluated against.
+	Updates []*updates.Update
+	// Outcomes scripts the result of operations, keyed by UpdateID.
+	// Updates without an Outcome succeed without requiring a reboot.
+	Outcomes map[string]Outcome
+	// Entries is the update history. Installs and uninstalls are appended.
+	Entries []*updatehistory.Entry
+	// Reboot is reported by RebootRequired and set by any operation that
+	// requires a reboot.
+	Reboot bool
+	// SearchErr, if set, is returned by Search.
+	SearchErr error
+	// HResult is reported by SearchHResult.
+	HResult string
+	// Now returns the time recorded in history entries. Defaults to time.Now.
+	Now func() time.Time
+
+	// Searches, Downloaded, Installed, Uninstalled, Hidden and Unhidden
+	// record the criteria and UpdateIDs passed to each operation.
+	Searches    []string
+	Downloaded  []string
+	Installed   []string
+	Uninstalled []string
+	Hidden      []string
+	Unhidden    []string
+	// Closed is set once Close has been called.
+	Closed bool
+
+	mu sync.Mutex
+}
+
+// Search returns the catalog updates matching the search criteria. Criteria
+// support the IsInstalled, IsHidden, Type, UpdateID and RebootRequired
+// properties, CategoryIDs contains, and the AND and OR operators. Other
+// properties are ignored.
+func (f *Fake) Search(criteria string) ([]*updates.Update, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Searches = append(f.Searches, criteria)
+	if f.SearchErr != nil {
+		return nil, f.SearchErr
+	}
+	var r []*updates.Update
+	for _, u := range f.Updates {
+		ok, err := match(criteria, u)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			r = append(r, u)
+		}
+	}
+	return r, nil
+}
+
+// SearchHResult returns the scripted search HResult.
+func (f *Fake) SearchHResult() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.HResult == "" {
+		return errors.SUCCESS.String()
+	}
+	return f.HResult
+}
+
+func (f *Fake) outcome(u *updates.Update) Outcome {
+	if o, ok := f.Outcomes[u.Identity.UpdateID]; ok {
+		return o
+	}
+	return Success
+}
+
+func (f *Fake) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
+
+func (f *Fake) record(u *updates.Update, op int, r Result, hr errors.UpdateError) {
+	f.Entries = append(f.Entries, &updatehistory.Entry{
+		Operation:      op,
+		ResultCode:     r.ResultCode,
+		HResult:        int(hr),
+		Date:           f.now(),
+		UpdateIdentity: u.Identity,
+		Title:          u.Title,
+		Categories:     u.Categories,
+	})
+	if r.RebootRequired {
+		f.Reboot = true
+	}
+}
+
+// Download returns the scripted download result code.
+func (f *Fake) Download(u *updates.Update) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Downloaded = append(f.Downloaded, u.Identity.UpdateID)
+	o := f.outcome(u)
+	if o.Err != nil {
+		return 0, o.Err
+	}
+	if o.DownloadResultCode == Succeeded {
+		u.IsDownloaded = true
+	}
+	return o.DownloadResultCode, nil
+}
+
+// Install returns the scripted install result and records it in the history.
+func (f *Fake) Install(u *updates.Update, commit bool) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Installed = append(f.Installed, u.Identity.UpdateID)
+	o := f.outcome(u)
+	if o.Err != nil {
+		return nil, o.Err
+	}
+	r := o.Install
+	f.record(u, updatehistory.Installation, r, o.HResult)
+	if r.ResultCode == Succeeded {
+		u.IsInstalled = true
+	}
+	return &r, nil
+}
+
+// Uninstall returns the scripted uninstall result and records it in the history.
+func (f *Fake) Uninstall(u *updates.Update) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Uninstalled = append(f.Uninstalled, u.Identity.UpdateID)
+	o := f.outcome(u)
+	if o.Err != nil {
+		return nil, o.Err
+	}
+	if !u.IsInstalled {
+		return nil, fmt.Errorf("uninstall error: [%s]", errors.WU_E_UNINSTALL_NOT_ALLOWED)
+	}
+	r := o.Uninstall
+	f.record(u, updatehistory.Uninstallation, r, o.HResult)
+	if r.ResultCode == Succeeded {
+		u.IsInstalled = false
+	}
+	return &r, nil
+}
+
+// AcceptEula marks the update license terms as accepted.
+func (f *Fake) AcceptEula(u *updates.Update) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u.EulaAccepted = true
+	return nil
+}
+
+// Hide marks the update as hidden.
+func (f *Fake) Hide(u *updates.Update) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Hidden = append(f.Hidden, u.Identity.UpdateID)
+	u.IsHidden = true
+	return nil
+}
+
+// UnHide marks the update as visible.
+func (f *Fake) UnHide(u *updates.Update) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Unhidden = append(f.Unhidden, u.Identity.UpdateID)
+	u.IsHidden = false
+	return nil
+}
+
+// History returns the scripted and recorded history entries matching filter,
+// most recent first.
+func (f *Fake) History(filter updatehistory.Filter) ([]*updatehistory.Entry, error) {
+	f.mu.Lock()
+	all := append([]*updatehistory.Entry(nil), f.Entries...)
+	f.mu.Unlock()
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Date.After(all[j].Date) })
+
+	var entries []*updatehistory.Entry
+	for _, e := range all {
+		if filter.Past(e) {
+			break
+		}
+		if !filter.Match(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// RebootRequired reports whether a reboot is pending.
+func (f *Fake) RebootRequired() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Reboot, nil
+}
+
+// Close marks the session as closed.
+func (f *Fake) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Closed = true
+}
+
+// match evaluates a subset of the Windows Update search criteria language.
+// AND takes precedence over OR.
+func match(criteria string, u *updates.Update) (bool, error) {
+	if strings.TrimSpace(criteria) == "" {
+		return true, nil
+	}
+	for _, or := range orSplit.Split(strings.TrimSpace(criteria), -1) {
+		all := true
+		for _, and := range andSplit.Split(or, -1) {
+			ok, err := matchTerm(strings.TrimSpace(and), u)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchTerm(term string, u *updates.Update) (bool, error) {
+	if m := contains.FindStringSubmatch(term); m != nil {
+		if !strings.EqualFold(m[1], "CategoryIDs") {
+			return true, nil
+		}
+		for _, c := range u.Categories {
+			if strings.EqualFold(c.CategoryID, m[2]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	m := equals.FindStringSubmatch(term)
+	if m == nil {
+		return false, fmt.Errorf("unsupported search criteria %q", term)
+	}
+	switch strings.ToLower(m[1]) {
+	case "isinstalled":
+		return matchBool(m[2], u.IsInstalled)
+	case "ishidden":
+		return matchBool(m[2], u.IsHidden)
+	case "rebootrequired":
+		return matchBool(m[2], u.RebootRequired)
+	case "type":
+		return strings.EqualFold(u.Type, m[2]), nil
+	case "updateid":
+		return strings.EqualFold(u.Identity.UpdateID, m[2]), nil
+	default:
+		return true, nil
+	}
+}
+
+func matchBool(v string, b bool) (bool, error) {
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean criteria value %q: %v", v, err)
+	}
+	return (i == 1) == b, nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/errors"
+	"github.com/google/cabbie/updatehistory"
+	"github.com/google/cabbie/updates"
+	"github.com/google/go-cmp/cmp"
+)
+
+const basicSearch = "IsInstalled=0 and DeploymentAction='Installation'"
+
+func testUpdates() []*updates.Update {
+	return []*updates.Update{
+		{Title: "Security Update", Identity: updates.Identity{UpdateID: "sec"}, KBArticleIDs: []string{"5031356"},
+			Categories: []updates.Category{{Name: "Security Updates", CategoryID: "0FA1201D-4330-4FA8-8AE9-B877473B6441"}}},
+		{Title: "Hidden Update", Identity: updates.Identity{UpdateID: "hidden"}, IsHidden: true},
+		{Title: "Installed Update", Identity: updates.Identity{UpdateID: "installed"}, IsInstalled: true, IsUninstallable: true},
+		{Title: "Driver", Identity: updates.Identity{UpdateID: "driver"}, Type: "Driver", DriverClass: "Display"},
+	}
+}
+
+func ids(us []*updates.Update) []string {
+	var r []string
+	for _, u := range us {
+		r = append(r, u.Identity.UpdateID)
+	}
+	return r
+}
+
+func TestSearch(t *testing.T) {
+	for _, tt := range []struct {
+		criteria string
+		want     []string
+	}{
+		{"", []string{"sec", "hidden", "installed", "driver"}},
+		{basicSearch + " AND IsHidden=0 OR Type='Driver'", []string{"sec", "driver"}},
+		{"IsHidden=1", []string{"hidden"}},
+		{"IsHidden=0 and IsInstalled=0 or IsHidden=0 and IsInstalled=1", []string{"sec", "installed", "driver"}},
+		{basicSearch + " AND CategoryIDs contains '0FA1201D-4330-4FA8-8AE9-B877473B6441'", []string{"sec"}},
+		{"UpdateID='installed'", []string{"installed"}},
+	} {
+		f := &Fake{Updates: testUpdates()}
+		got, err := f.Search(tt.criteria)
+		if err != nil {
+			t.Errorf("Search(%q) returned unexpected error: %v", tt.criteria, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, ids(got)); diff != "" {
+			t.Errorf("Search(%q) returned unexpected diff (-want +got):\n%s", tt.criteria, diff)
+		}
+	}
+}
+
+func TestSearchInvalidCriteria(t *testing.T) {
+	f := &Fake{Updates: testUpdates()}
+	if _, err := f.Search("IsHidden=yes"); err == nil {
+		t.Errorf("Search(%q) returned nil error, want error", "IsHidden=yes")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	f := &Fake{
+		Updates: testUpdates(),
+		Outcomes: map[string]Outcome{
+			"driver": {
+				DownloadResultCode: Succeeded,
+				Install:            Result{ResultCode: Failed, HResult: errors.WU_E_INSTALL_NOT_ALLOWED.String()},
+				HResult:            errors.WU_E_INSTALL_NOT_ALLOWED,
+			},
+			"sec": {
+				DownloadResultCode: Succeeded,
+				Install:            Result{ResultCode: Succeeded, RebootRequired: true},
+			},
+		},
+	}
+	us, err := f.Search(basicSearch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range us {
+		if u.IsHidden {
+			continue
+		}
+		rc, err := f.Download(u)
+		if err != nil || rc != Succeeded {
+			t.Fatalf("Download(%s) = %d, %v, want %d, nil", u.Identity.UpdateID, rc, err, Succeeded)
+		}
+		if _, err := f.Install(u, false); err != nil {
+			t.Fatalf("Install(%s) returned unexpected error: %v", u.Identity.UpdateID, err)
+		}
+	}
+	if diff := cmp.Diff([]string{"sec", "driver"}, f.Installed); diff != "" {
+		t.Errorf("Installed returned unexpected diff (-want +got):\n%s", diff)
+	}
+	if !us[0].IsInstalled || us[2].IsInstalled {
+		t.Errorf("IsInstalled = %t, %t, want true, false", us[0].IsInstalled, us[2].IsInstalled)
+	}
+	rb, _ := f.RebootRequired()
+	if !rb {
+		t.Errorf("RebootRequired() = false, want true")
+	}
+	h, _ := f.History(updatehistory.Filter{Result: "failed"})
+	if len(h) != 1 || h[0].HResult != int(errors.WU_E_INSTALL_NOT_ALLOWED) {
+		t.Errorf("History(failed) = %+v, want one entry with the scripted HResult", h)
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	f := &Fake{Updates: testUpdates()}
+	us, err := f.Search("IsInstalled=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := f.Uninstall(us[0])
+	if err != nil {
+		t.Fatalf("Uninstall() returned unexpected error: %v", err)
+	}
+	if r.ResultCode != Succeeded || us[0].IsInstalled {
+		t.Errorf("Uninstall() = %+v, IsInstalled %t, want success and not installed", r, us[0].IsInstalled)
+	}
+	if _, err := f.Uninstall(us[0]); err == nil {
+		t.Errorf("Uninstall() of a removed update returned nil error, want error")
+	}
+}
+
+func TestHide(t *testing.T) {
+	f := &Fake{Updates: testUpdates()}
+	if err := f.Hide(f.Updates[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.UnHide(f.Updates[1]); err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.Search("IsHidden=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"sec"}, ids(got)); diff != "" {
+		t.Errorf("Search(IsHidden=1) returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	f := &Fake{}
+	for i := 0; i < 5; i++ {
+		f.Entries = append(f.Entries, &updatehistory.Entry{Operation: updatehistory.Installation, ResultCode: Succeeded, Date: day.AddDate(0, 0, i), Title: "Update"})
+	}
+	for _, tt := range []struct {
+		f    updatehistory.Filter
+		want []int
+	}{
+		{updatehistory.Filter{}, []int{5, 4, 3, 2, 1}},
+		{updatehistory.Filter{Limit: 2}, []int{5, 4}},
+		{updatehistory.Filter{Since: day.AddDate(0, 0, 3)}, []int{5, 4}},
+		{updatehistory.Filter{Until: day.AddDate(0, 0, 1), Limit: 1}, []int{2}},
+	} {
+		h, err := f.History(tt.f)
+		if err != nil {
+			t.Fatalf("History(%+v) returned unexpected error: %v", tt.f, err)
+		}
+		var got []int
+		for _, e := range h {
+			got = append(got, e.Date.Day())
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("History(%+v) returned unexpected diff (-want +got):\n%s", tt.f, diff)
+		}
+	}
+}
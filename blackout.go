@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/cabbie/blackout"
+	"github.com/google/cabbie/cablib"
+	"github.com/google/deck"
+)
+
+var (
+	// rebootOverride is the reboot time set for updates installed despite a
+	// blackout, so that this reboot is not held until the blackout ends. Once
+	// it is carried out, cleared or rescheduled, the pending reboot time no
+	// longer matches, and later reboots are held again.
+	rebootOverride blackoutOverride
+
+	// pendingReboot returns the pending reboot time. Tests replace it.
+	pendingReboot = cablib.RebootTime
+)
+
+type blackoutOverride struct {
+	mutex sync.Mutex
+	at    time.Time
+}
+
+func (b *blackoutOverride) set(t time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.at = t
+}
+
+// covers returns true if the override was set for the reboot at t.
+func (b *blackoutOverride) covers(t time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return !b.at.IsZero() && b.at.Equal(t)
+}
+
+// blackoutCalendar returns the blackout calendar, logging the entries that
+// were skipped. If the calendar cannot be read at all, the error is logged and
+// no blackout applies, rather than holding updates indefinitely.
+func blackoutCalendar() blackout.Calendar {
+	c, fileErrs, err := loadBlackout()
+	if err != nil {
+		deck.ErrorfA("Error reading blackout calendars from %q, no blackout applies:\n%v", blackout.Dir(), err).With(eventID(cablib.EvtErrConfig)).Go()
+		return nil
+	}
+	for _, e := range fileErrs {
+		deck.ErrorfA("Skipping blackout calendar entry:\n%v", e).With(eventID(cablib.EvtErrConfig)).Go()
+	}
+	return c
+}
+
+// activeBlackout returns the blackout period in effect at t, if any.
+func activeBlackout(t time.Time) (blackout.Period, bool) {
+	return blackoutCalendar().Active(t)
+}
+
+// rebootHold returns when the blackout in effect at t ends, or the zero time
+// if none is or the pending reboot overrides it. See cablib.SystemReboot.
+func rebootHold(t time.Time) time.Time {
+	if at, err := pendingReboot(); err == nil && rebootOverride.covers(at) {
+		return time.Time{}
+	}
+	until := blackoutCalendar().Until(t)
+	if !until.IsZero() {
+		deck.InfofA("Blackout in effect, holding reboot until %s.", until).With(eventID(cablib.EvtBlackout)).Go()
+	}
+	return until
+}
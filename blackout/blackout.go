@@ -0,0 +1,209 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blackout reads the change freeze calendar, the periods in which
+// Cabbie neither installs updates nor restarts the device.
+package blackout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	errFileType = errors.New("file is not .ics or .json")
+
+	blackoutDir = filepath.Join(os.Getenv("ProgramData"), `\Cabbie\blackout`)
+)
+
+// Period is a time range in which changes are frozen, from Start up to, but
+// not including, End.
+type Period struct {
+	Name   string
+	Start  time.Time
+	End    time.Time
+	Source string
+}
+
+// Contains returns true if t is within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+func (p Period) String() string {
+	s := fmt.Sprintf("%s - %s", p.Start.Format(time.RFC3339), p.End.Format(time.RFC3339))
+	if p.Name != "" {
+		s = fmt.Sprintf("%q (%s)", p.Name, s)
+	}
+	return s
+}
+
+// Calendar is a set of blackout periods, sorted by start.
+type Calendar []Period
+
+// Active returns the period in effect at t. If several periods overlap, the
+// one ending last is returned.
+func (c Calendar) Active(t time.Time) (Period, bool) {
+	var found Period
+	ok := false
+	for _, p := range c {
+		if p.Contains(t) && (!ok || p.End.After(found.End)) {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+// Until returns when the blackout in effect at t ends, following periods
+// that overlap or adjoin it, or the zero time if none is in effect.
+func (c Calendar) Until(t time.Time) time.Time {
+	var end time.Time
+	for {
+		p, ok := c.Active(t)
+		if !ok {
+			return end
+		}
+		end, t = p.End, p.End
+	}
+}
+
+// FileError reports a calendar file, or an entry of one, that was skipped.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// Dir returns the directory blackout calendars are read from.
+func Dir() string {
+	return blackoutDir
+}
+
+// Load returns the periods of every calendar in the blackout directory. A
+// missing directory is an empty calendar. Entries that cannot be used are
+// skipped and reported individually.
+func Load() (Calendar, []FileError, error) {
+	return load(blackoutDir)
+}
+
+func load(dir string) (Calendar, []FileError, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var c Calendar
+	var fileErrs []FileError
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		ps, errs := ReadFile(path)
+		for _, err := range errs {
+			fileErrs = append(fileErrs, FileError{Path: path, Err: err})
+		}
+		c = append(c, ps...)
+	}
+	sort.SliceStable(c, func(i, j int) bool { return c[i].Start.Before(c[j].Start) })
+	return c, fileErrs, nil
+}
+
+// ReadFile returns the periods of an iCalendar (.ics) or JSON calendar file,
+// and an error for each entry that was skipped.
+func ReadFile(path string) ([]Period, []error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []error{err}
+	}
+	var ps []Period
+	var errs []error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics":
+		ps, errs = parseICS(data)
+	case ".json":
+		ps, errs = parseJSON(data)
+	default:
+		return nil, []error{errFileType}
+	}
+	for i := range ps {
+		ps[i].Source = path
+	}
+	return ps, errs
+}
+
+// jsonPeriod is an entry of a JSON calendar. Times are written in RFC 3339
+// form or as dates, which are local. An end date includes the whole day.
+type jsonPeriod struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func parseJSON(data []byte) ([]Period, []error) {
+	var entries []jsonPeriod
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, []error{err}
+	}
+	var ps []Period
+	var errs []error
+	for i, e := range entries {
+		start, _, err := parseTime(e.Start)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: start: %v", i, err))
+			continue
+		}
+		end, date, err := parseTime(e.End)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: end: %v", i, err))
+			continue
+		}
+		if date {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !end.After(start) {
+			errs = append(errs, fmt.Errorf("entry %d: end %s is not after start %s", i, e.End, e.Start))
+			continue
+		}
+		ps = append(ps, Period{Name: e.Name, Start: start, End: end})
+	}
+	return ps, errs
+}
+
+// parseTime parses an RFC 3339 time or a local date, and reports which it was.
+func parseTime(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return t, false, fmt.Errorf("invalid time %q, want RFC 3339 or YYYY-MM-DD", s)
+	}
+	return t, true, nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blackout
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func local(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func TestReadFileICS(t *testing.T) {
+	path := filepath.Join("testdata", "freeze.ics")
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Period{
+		{Name: "Holiday freeze", Start: local(2026, 12, 21), End: local(2027, 1, 4), Source: path},
+		{Name: "Quarter close, Q3", Start: time.Date(2026, 9, 28, 18, 0, 0, 0, ny), End: time.Date(2026, 10, 2, 0, 0, 0, 0, ny), Source: path},
+	}
+	got, errs := ReadFile(path)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadFile(%q) returned unexpected diff (-want +got):\n%s", path, diff)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], errRecurring) {
+		t.Errorf("ReadFile(%q) returned errors %v, want one for the recurring event", path, errs)
+	}
+}
+
+func TestICSEventNoEnd(t *testing.T) {
+	props := []icsProperty{{name: "SUMMARY", value: "Launch"}, {name: "DTSTART", value: "20261110T080000Z"}}
+	_, err := icsEvent(props)
+	if err == nil || !strings.Contains(err.Error(), "no DTEND or DURATION") {
+		t.Errorf("icsEvent(%v) returned error %v, want one for the missing DTEND or DURATION", props, err)
+	}
+}
+
+func TestReadFileJSON(t *testing.T) {
+	path := filepath.Join("testdata", "freeze.json")
+	want := []Period{
+		{Name: "Quarter close", Start: time.Date(2026, 12, 28, 0, 0, 0, 0, time.UTC), End: local(2027, 1, 1), Source: path},
+		{Name: "Launch", Start: time.Date(2026, 11, 10, 8, 0, 0, 0, time.UTC), End: time.Date(2026, 11, 10, 20, 0, 0, 0, time.UTC), Source: path},
+	}
+	got, errs := ReadFile(path)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadFile(%q) returned unexpected diff (-want +got):\n%s", path, diff)
+	}
+	if len(errs) != 1 {
+		t.Errorf("ReadFile(%q) returned errors %v, want one for the backwards entry", path, errs)
+	}
+}
+
+func TestICSDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"P1W", 7 * 24 * time.Hour, false},
+		{"P1DT12H", 36 * time.Hour, false},
+		{"PT90M", 90 * time.Minute, false},
+		{"P", 0, true},
+		{"1D", 0, true},
+		{"P1X", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := icsDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("icsDuration(%q) = %v, %v, want %v, error: %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	c, errs, err := load("testdata")
+	if err != nil {
+		t.Fatalf("load(testdata) returned unexpected error: %v", err)
+	}
+	if len(c) != 4 || len(errs) != 2 {
+		t.Errorf("load(testdata) = %d periods and errors %v, want 4 periods and 2 errors", len(c), errs)
+	}
+	for i := 1; i < len(c); i++ {
+		if c[i].Start.Before(c[i-1].Start) {
+			t.Errorf("load(testdata) periods are not sorted: %v", c)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "missing")
+	if c, _, err := load(dir); err != nil || len(c) != 0 {
+		t.Errorf("load(%q) = %v, %v, want an empty calendar", dir, c, err)
+	}
+
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, errs, _ := load(dir); len(errs) != 1 || !errors.Is(errs[0], errFileType) {
+		t.Errorf("load(%q) returned errors %v, want %v", dir, errs, errFileType)
+	}
+}
+
+func TestActive(t *testing.T) {
+	at := func(d, h int) time.Time { return time.Date(2026, 12, d, h, 0, 0, 0, time.UTC) }
+	c := Calendar{
+		{Name: "a", Start: at(1, 0), End: at(3, 0)},
+		{Name: "b", Start: at(2, 0), End: at(5, 0)},
+		{Name: "c", Start: at(5, 0), End: at(6, 0)},
+		{Name: "d", Start: at(10, 0), End: at(11, 0)},
+	}
+	tests := []struct {
+		in    time.Time
+		name  string
+		until time.Time
+	}{
+		{at(1, 12), "a", at(6, 0)},
+		{at(2, 12), "b", at(6, 0)},
+		{at(5, 0), "c", at(6, 0)},
+		{at(6, 0), "", time.Time{}},
+		{at(10, 6), "d", at(11, 0)},
+	}
+	for _, tt := range tests {
+		p, ok := c.Active(tt.in)
+		if ok != (tt.name != "") || p.Name != tt.name {
+			t.Errorf("Active(%v) = %v, %t, want %q", tt.in, p, ok, tt.name)
+		}
+		if got := c.Until(tt.in); !got.Equal(tt.until) {
+			t.Errorf("Until(%v) = %v, want %v", tt.in, got, tt.until)
+		}
+	}
+}
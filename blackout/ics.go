@@ -0,0 +1,199 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blackout
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var errRecurring = errors.New("recurring events are not supported")
+
+// icsProperty is a content line of an iCalendar file, such as
+// DTSTART;TZID=Europe/Zurich:20261224T000000.
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfold joins the folded lines of an iCalendar file, see RFC 5545 3.1.
+func unfold(data []byte) []string {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func parseProperty(line string) (icsProperty, bool) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return icsProperty{}, false
+	}
+	parts := strings.Split(line[:i], ";")
+	p := icsProperty{name: strings.ToUpper(parts[0]), params: make(map[string]string), value: line[i+1:]}
+	for _, kv := range parts[1:] {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return p, true
+}
+
+// icsTime parses a DATE or DATE-TIME value, and reports whether it was a date.
+// Times without a time zone are local.
+func icsTime(p icsProperty) (time.Time, bool, error) {
+	if p.params["VALUE"] == "DATE" || len(p.value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", p.value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", p.value)
+		return t, false, err
+	}
+	loc := time.Local
+	if tz := p.params["TZID"]; tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", p.value, loc)
+	return t, false, err
+}
+
+// unescape decodes the escaped characters of a TEXT value.
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseICS returns the events of an iCalendar file as periods. Only the
+// SUMMARY, DTSTART, DTEND and DURATION properties of events are used.
+func parseICS(data []byte) ([]Period, []error) {
+	var ps []Period
+	var errs []error
+	var event []icsProperty
+	inEvent := false
+	for _, line := range unfold(data) {
+		p, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			inEvent, event = true, nil
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			inEvent = false
+			per, err := icsEvent(event)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			ps = append(ps, per)
+		case inEvent:
+			event = append(event, p)
+		}
+	}
+	if len(ps) == 0 && len(errs) == 0 && !strings.Contains(string(data), "BEGIN:VCALENDAR") {
+		errs = append(errs, errors.New("not an iCalendar file"))
+	}
+	return ps, errs
+}
+
+func icsEvent(props []icsProperty) (Period, error) {
+	var per Period
+	var start, end icsProperty
+	var duration string
+	for _, p := range props {
+		switch p.name {
+		case "SUMMARY":
+			per.Name = unescape(p.value)
+		case "DTSTART":
+			start = p
+		case "DTEND":
+			end = p
+		case "DURATION":
+			duration = p.value
+		case "RRULE", "RDATE":
+			return per, fmt.Errorf("event %q: %w", per.Name, errRecurring)
+		}
+	}
+	if start.value == "" {
+		return per, fmt.Errorf("event %q has no DTSTART", per.Name)
+	}
+	var date bool
+	var err error
+	if per.Start, date, err = icsTime(start); err != nil {
+		return per, fmt.Errorf("event %q: DTSTART: %v", per.Name, err)
+	}
+	switch {
+	case end.value != "":
+		if per.End, _, err = icsTime(end); err != nil {
+			return per, fmt.Errorf("event %q: DTEND: %v", per.Name, err)
+		}
+	case duration != "":
+		d, err := icsDuration(duration)
+		if err != nil {
+			return per, fmt.Errorf("event %q: DURATION: %v", per.Name, err)
+		}
+		per.End = per.Start.Add(d)
+	case date:
+		// An all-day event without an end lasts one day.
+		per.End = per.Start.AddDate(0, 0, 1)
+	default:
+		return per, fmt.Errorf("event %q has no DTEND or DURATION", per.Name)
+	}
+	if !per.End.After(per.Start) {
+		return per, fmt.Errorf("event %q ends before it starts", per.Name)
+	}
+	return per, nil
+}
+
+// icsDuration parses a positive DURATION value such as P1W or P1DT12H.
+func icsDuration(s string) (time.Duration, error) {
+	v := strings.TrimPrefix(s, "+")
+	if !strings.HasPrefix(v, "P") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	n := 0
+	digits := false
+	for i := 1; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case c == 'T':
+		case c >= '0' && c <= '9':
+			n = n*10 + int(c-'0')
+			digits = true
+		case units[c] != 0 && digits:
+			d += time.Duration(n) * units[c]
+			n, digits = 0, false
+		default:
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	if digits || d == 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
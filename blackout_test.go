@@ -0,0 +1,45 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/blackout"
+)
+
+func TestRebootHoldAfterOverride(t *testing.T) {
+	now := time.Now()
+	end := now.Add(time.Hour)
+	defer func(f func() (blackout.Calendar, []blackout.FileError, error)) { loadBlackout = f }(loadBlackout)
+	loadBlackout = func() (blackout.Calendar, []blackout.FileError, error) {
+		return blackout.Calendar{{Name: "Freeze", Start: now.Add(-time.Hour), End: end}}, nil, nil
+	}
+	defer func(f func() (time.Time, error)) { pendingReboot = f }(pendingReboot)
+	defer rebootOverride.set(time.Time{})
+
+	first := now.Add(time.Minute)
+	pendingReboot = func() (time.Time, error) { return first, nil }
+	rebootOverride.set(first)
+	if got := rebootHold(now); !got.IsZero() {
+		t.Errorf("rebootHold() of the overriding reboot = %s, want the zero time", got)
+	}
+
+	// A later reboot is held again.
+	pendingReboot = func() (time.Time, error) { return now.Add(2 * time.Minute), nil }
+	if got := rebootHold(now); !got.Equal(end) {
+		t.Errorf("rebootHold() of a later reboot = %s, want %s", got, end)
+	}
+}
@@ -33,13 +33,19 @@ import (
 	"flag"
 	"github.com/google/cabbie/metrics"
 	"github.com/google/cabbie/notification"
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/blackout"
 	"github.com/google/cabbie/cablib"
 	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/jobs"
+	"github.com/google/cabbie/policy"
+	"github.com/google/cabbie/schedule"
 	"github.com/google/cabbie/servicemgr"
 	"github.com/google/deck/backends/eventlog"
 	"github.com/google/deck/backends/logger"
 	"github.com/google/deck"
 	"github.com/google/aukera/client"
+	"github.com/google/aukera/window"
 	"github.com/scjalliance/comshim"
 	"golang.org/x/sys/windows/registry"
 	"golang.org/x/sys/windows/svc/debug"
@@ -68,10 +74,26 @@ var (
 	requiredUpdateCount        = new(metrics.Int)
 	enforcedUpdateCount        = new(metrics.Int)
 	enforcementWatcherFailures = new(metrics.Int)
+	invalidEnforcementFiles    = new(metrics.Int)
+	remoteEnforcementSuccess   = new(metrics.Bool)
+	hiddenDriftCount           = new(metrics.Int)
 	installHResult             = new(metrics.String)
 	searchHResult              = new(metrics.String)
 
 	eventID = eventlog.EventID
+
+	// newAgent starts a Windows Update session. Tests replace it with an agent.Fake.
+	newAgent = func() (agent.Agent, error) {
+		return agent.NewWUA(config.WSUSServers, config.EnableThirdParty)
+	}
+
+	// hiddenUpdates and setHiddenUpdates load and store the UpdateIDs hidden
+	// because of an enforcement. Tests replace them.
+	hiddenUpdates    = cablib.GetHiddenUpdates
+	setHiddenUpdates = cablib.SetHiddenUpdates
+
+	// loadBlackout loads the blackout calendar. Tests replace it.
+	loadBlackout = blackout.Load
 )
 
 // Settings contains configurable options.
@@ -79,6 +101,10 @@ type Settings struct {
 	WSUSServers, RequiredCategories                                                                                       []string
 	InstallDrivers, InstallVirusDefs, EnableThirdParty, RebootDelay, Deadline, EnableNotifications, InstallMonthlyPatches uint64
 
+	// DeadlineRebootDelay replaces RebootDelay for updates installed past
+	// their enforcement install-by deadline.
+	DeadlineRebootDelay uint64
+
 	// Aukera Integration
 	AukeraEnabled uint64
 	AukeraPort    uint64
@@ -90,37 +116,219 @@ type Settings struct {
 	PprofPort uint64
 
 	ScriptTimeout time.Duration
+
+	// EnforcementURL is an HTTPS URL to fetch an enforcement document from.
+	EnforcementURL string
+
+	// EnforcementKeys are the base64 ed25519 public keys enforcement files
+	// may be signed with. RequireSignedEnforcement rejects unsigned files.
+	EnforcementKeys          []string
+	RequireSignedEnforcement uint64
+
+	// Schedule is a cron expression of when maintenance windows open, for
+	// hosts without Aukera. Each window lasts Duration, and the expression is
+	// evaluated in the IANA time zone TimeZone, or local time if empty.
+	Schedule string
+	Duration time.Duration
+	TimeZone string
+
+	// Splay is the upper bound of a per-host delay added to every run of the
+	// service jobs, and Jitter the upper bound of a random delay per job. Both
+	// are bounded by spreadSetting.
+	Splay  time.Duration
+	Jitter map[jobs.Job]time.Duration
+
+	// Intervals is how often each service job runs, and AukeraInterval how
+	// often Aukera is polled, see jobIntervals.
+	Intervals      map[jobs.Job]time.Duration
+	AukeraInterval time.Duration
+
+	// ReconcileHidden unhides updates Cabbie hid because of an enforcement
+	// once no enforcement hides them anymore.
+	ReconcileHidden uint64
+
+	// Ring is the deployment ring of the host, and Rings the per-category
+	// deferrals of each ring. Without Ring, the host is assigned a ring from
+	// RingShares, see policy.HostRing.
+	Ring       string
+	Rings      map[string]policy.Ring
+	RingShares []policy.RingShare
+
+	// InstallAfterPatchTuesdayDays, if not negative, holds updates until that
+	// many days after the Patch Tuesday of their release, counted in TimeZone.
+	// SkipPreviewReleases skips preview releases.
+	InstallAfterPatchTuesdayDays int
+	SkipPreviewReleases          uint64
+}
+
+// jobStartDelay is how long after the service starts overdue jobs run.
+const jobStartDelay = 5 * time.Minute
+
+// intervalSetting is the default and bounds of a configurable job interval.
+type intervalSetting struct {
+	def, min, max time.Duration
+}
+
+// jobIntervals is how often each service job runs, before spread, unless
+// configured otherwise.
+var jobIntervals = map[jobs.Job]intervalSetting{
+	jobs.Install:     {24 * time.Hour, time.Hour, 7 * 24 * time.Hour},
+	jobs.List:        {2 * time.Hour, 15 * time.Minute, 7 * 24 * time.Hour},
+	jobs.Virus:       {30 * time.Minute, 15 * time.Minute, 7 * 24 * time.Hour},
+	jobs.Driver:      {72 * time.Hour, time.Hour, 30 * 24 * time.Hour},
+	jobs.Enforcement: {6 * time.Hour, 15 * time.Minute, 7 * 24 * time.Hour},
+	jobs.Remote:      {time.Hour, 15 * time.Minute, 24 * time.Hour},
+}
+
+// aukeraInterval is how often Aukera is polled for the maintenance window.
+var aukeraInterval = intervalSetting{5 * time.Minute, time.Minute, time.Hour}
+
+// spreadSetting bounds the Splay and <Job>Jitter of the service jobs, which are
+// further capped to the job interval, see jobs.Spread.
+var spreadSetting = intervalSetting{0, 0, 24 * time.Hour}
+
+// parse returns the duration s, which must be within the bounds of i.
+func (i intervalSetting) parse(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < i.min || d > i.max {
+		return 0, fmt.Errorf("%v is not between %v and %v", d, i.min, i.max)
+	}
+	return d, nil
 }
 
 type tickers struct {
-	Default, Aukera, List, Virus, Driver, Enforcement *time.Ticker
+	// Jobs are scheduled from their last recorded run, see jobs.Timer.
+	Default, List, Virus, Driver, Enforcement, Remote *jobs.Timer
+	Aukera                                            *time.Ticker
 }
 
+// driverExcludes holds the driver exclusions of the enforcements, along with
+// the files they were read from.
 type driverExcludes struct {
-	mutex sync.Mutex
-	e     []enforcement.DriverExclude
+	mutex   sync.Mutex
+	e       []enforcement.DriverExclude
+	origins map[string][]enforcement.Origin
 }
 
-func (d *driverExcludes) set(v []enforcement.DriverExclude) {
+func (d *driverExcludes) set(v enforcement.Enforcements) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
-	d.e = v
+	d.e = v.ExcludedDrivers
+	d.origins = v.Origins
 }
 
-func (d *driverExcludes) get() []enforcement.DriverExclude {
+func (d *driverExcludes) get() enforcement.Enforcements {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
-	return d.e
+	return enforcement.Enforcements{ExcludedDrivers: d.e, Origins: d.origins}
+}
+
+// deploymentRing returns the deployment ring of the host, see Settings.Ring.
+func (s *Settings) deploymentRing() policy.Ring {
+	name := s.Ring
+	if name == "" && len(s.RingShares) > 0 {
+		host, err := os.Hostname()
+		if err != nil {
+			deck.ErrorfA("Error getting the host name, using no deployment ring:\n%v", err).With(eventID(cablib.EvtErrConfig)).Go()
+			return policy.Ring{}
+		}
+		name = policy.HostRing(host, s.RingShares)
+	}
+	if r, ok := s.Rings[name]; ok {
+		return r
+	}
+	return policy.Ring{Name: name}
+}
+
+// patchDelay returns the delay after Patch Tuesday updates are held for, or
+// nil if they are not held.
+func (s *Settings) patchDelay() *schedule.PatchDelay {
+	if s.InstallAfterPatchTuesdayDays < 0 {
+		return nil
+	}
+	d, err := schedule.NewPatchDelay(s.InstallAfterPatchTuesdayDays, s.TimeZone)
+	if err != nil {
+		deck.ErrorfA("Invalid Patch Tuesday delay, not holding updates after Patch Tuesday:\n%v", err).With(eventID(cablib.EvtErrConfig)).Go()
+		return nil
+	}
+	return d
+}
+
+// jobSpread returns the delays added to the runs of j, see jobs.Spread.
+func (s *Settings) jobSpread(j jobs.Job) jobs.Spread {
+	host, err := os.Hostname()
+	if err != nil {
+		deck.ErrorfA("Error getting the host name, using no splay:\n%v", err).With(eventID(cablib.EvtErrConfig)).Go()
+		return jobs.Spread{Jitter: s.Jitter[j]}
+	}
+	return jobs.Spread{Splay: jobs.HostSplay(host, j, s.Splay), Jitter: s.Jitter[j]}
 }
 
 func initTickers() tickers {
+	now := time.Now()
+	job := func(j jobs.Job) *jobs.Timer {
+		t, err := jobs.NewTimer(jobs.Registry{}, j, config.Intervals[j], jobStartDelay, config.jobSpread(j), now)
+		if err != nil {
+			deck.ErrorfA("Error loading the last run of the %s job, running it after startup:\n%v", j, err).With(eventID(cablib.EvtErrConfig)).Go()
+		}
+		return t
+	}
 	return tickers{
-		Default:     time.NewTicker(24 * time.Hour),
-		Aukera:      time.NewTicker(5 * time.Minute),
-		List:        time.NewTicker(2 * time.Hour),
-		Virus:       time.NewTicker(30 * time.Minute),
-		Driver:      time.NewTicker(72 * time.Hour),
-		Enforcement: time.NewTicker(6 * time.Hour),
+		Default:     job(jobs.Install),
+		Aukera:      time.NewTicker(config.AukeraInterval),
+		List:        job(jobs.List),
+		Virus:       job(jobs.Virus),
+		Driver:      job(jobs.Driver),
+		Enforcement: job(jobs.Enforcement),
+		Remote:      job(jobs.Remote),
+	}
+}
+
+// logJobs reports when each running job is due, and its spread.
+func (t *tickers) logJobs() {
+	var s []string
+	for _, j := range []*jobs.Timer{t.Default, t.List, t.Virus, t.Driver, t.Enforcement, t.Remote} {
+		if due := j.Due(); !due.IsZero() {
+			s = append(s, fmt.Sprintf("%s: every %v, next at %s (%s)", j.Job(), j.Interval(), due.Format(time.RFC3339), j.Spread()))
+		}
+	}
+	deck.InfofA("Job schedule:\n%s", strings.Join(s, "\n")).With(eventID(cablib.EvtMisc)).Go()
+}
+
+// armWindowTimer sets timer to fire at e, delaying window openings by the
+// install spread within the first half of the window.
+func armWindowTimer(timer *time.Timer, e schedule.Event) {
+	at := e.Time
+	if e.Open && !at.IsZero() {
+		d := config.jobSpread(jobs.Install).Cap(e.Window.Closes.Sub(e.Window.Opens) / 2).Delay()
+		deck.InfofA("Maintenance window %s opens, installing after %v.", e.Window, d).With(eventID(cablib.EvtMisc)).Go()
+		at = at.Add(d)
+	}
+	armRuleTimer(timer, at)
+}
+
+// armPatchTimer sets timer to fire on the next install day of p after now,
+// delayed by the install spread.
+func armPatchTimer(timer *time.Timer, p *schedule.PatchDelay, now time.Time) {
+	at := p.Next(now)
+	d := config.jobSpread(jobs.Install).Cap(config.Intervals[jobs.Install]).Delay()
+	deck.InfofA("Next install %s on %s, after %v.", p, at.Format(time.RFC3339), d).With(eventID(cablib.EvtMisc)).Go()
+	armRuleTimer(timer, at.Add(d))
+}
+
+// aukeraSplay returns how long after w opens updates are installed, within the
+// first half of the window.
+func aukeraSplay(w window.Schedule) time.Duration {
+	return config.jobSpread(jobs.Install).Cap(w.Closes.Sub(w.Opens) / 2).Splay
+}
+
+// jobDone records a run of the job of t that started at start.
+func jobDone(t *jobs.Timer, start time.Time, err error) {
+	if e := t.Done(start, err == nil); e != nil {
+		deck.ErrorfA("Error recording the last run of the %s job:\n%v", t.Job(), e).With(eventID(cablib.EvtErrConfig)).Go()
 	}
 }
 
@@ -131,6 +339,15 @@ func (t *tickers) stop() {
 	t.Virus.Stop()
 	t.Driver.Stop()
 	t.Enforcement.Stop()
+	t.Remote.Stop()
+}
+
+func defaultIntervals() map[jobs.Job]time.Duration {
+	m := make(map[jobs.Job]time.Duration)
+	for j, i := range jobIntervals {
+		m[j] = i.def
+	}
+	return m
 }
 
 func newSettings() *Settings {
@@ -141,10 +358,16 @@ func newSettings() *Settings {
 		InstallVirusDefs:      1,
 		InstallMonthlyPatches: 1,
 		RebootDelay:           21600,
+		DeadlineRebootDelay:   3600,
 		Deadline:              14,
 		EnableNotifications:   1,
 		AukeraPort:            9119,
 		ScriptTimeout:         10 * time.Minute,
+		Duration:              4 * time.Hour,
+		Intervals:             defaultIntervals(),
+		AukeraInterval:        aukeraInterval.def,
+
+		InstallAfterPatchTuesdayDays: -1,
 	}
 }
 
@@ -193,6 +416,9 @@ func (s *Settings) regLoad(path string) error {
 	if i, _, err := k.GetIntegerValue("Deadline"); err == nil {
 		s.Deadline = i
 	}
+	if i, _, err := k.GetIntegerValue("DeadlineRebootDelay"); err == nil {
+		s.DeadlineRebootDelay = i
+	}
 	if i, _, err := k.GetIntegerValue("EnableNotifications"); err == nil {
 		s.EnableNotifications = i
 	} else if i, _, err := k.GetIntegerValue("NotifyAvailable"); err == nil {
@@ -217,6 +443,126 @@ func (s *Settings) regLoad(path string) error {
 	if i, _, err := k.GetIntegerValue("InstallMonthlyPatches"); err == nil {
 		s.InstallMonthlyPatches = i
 	}
+	if u, _, err := k.GetStringValue("EnforcementURL"); err == nil {
+		s.EnforcementURL = u
+	}
+	if m, _, err := k.GetStringsValue("EnforcementKeys"); err == nil {
+		s.EnforcementKeys = m
+	}
+	if i, _, err := k.GetIntegerValue("RequireSignedEnforcement"); err == nil {
+		s.RequireSignedEnforcement = i
+	}
+	if c, _, err := k.GetStringValue("Schedule"); err == nil {
+		s.Schedule = c
+	}
+	if d, _, err := k.GetStringValue("Duration"); err == nil {
+		if v, err := time.ParseDuration(d); err == nil {
+			s.Duration = v
+		} else {
+			deck.ErrorfA("Invalid Duration %q, using default %v:\n%v", d, s.Duration, err).With(eventID(cablib.EvtErrConfig)).Go()
+		}
+	}
+	if z, _, err := k.GetStringValue("TimeZone"); err == nil {
+		s.TimeZone = z
+	}
+	if i, _, err := k.GetIntegerValue("InstallAfterPatchTuesdayDays"); err == nil {
+		if i <= schedule.MaxPatchDays {
+			s.InstallAfterPatchTuesdayDays = int(i)
+		} else {
+			deck.ErrorfA("Invalid InstallAfterPatchTuesdayDays %d, not between 0 and %d; not holding updates after Patch Tuesday.", i, schedule.MaxPatchDays).With(eventID(cablib.EvtErrConfig)).Go()
+		}
+	}
+	if i, _, err := k.GetIntegerValue("SkipPreviewReleases"); err == nil {
+		s.SkipPreviewReleases = i
+	}
+	if v, _, err := k.GetStringValue("Splay"); err == nil {
+		if d, err := spreadSetting.parse(v); err == nil {
+			s.Splay = d
+		} else {
+			deck.ErrorfA("Invalid Splay %q, using default %v:\n%v", v, s.Splay, err).With(eventID(cablib.EvtErrConfig)).Go()
+		}
+	} else if err == registry.ErrUnexpectedType {
+		deck.ErrorfA("Invalid Splay, want a duration such as \"30m\"; using default %v.", s.Splay).With(eventID(cablib.EvtErrConfig)).Go()
+	}
+	for _, j := range jobs.Jobs {
+		name := string(j) + "Jitter"
+		v, _, err := k.GetStringValue(name)
+		if err == registry.ErrUnexpectedType {
+			deck.ErrorfA("Invalid %s, want a duration such as \"30m\"; using no jitter.", name).With(eventID(cablib.EvtErrConfig)).Go()
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		d, err := spreadSetting.parse(v)
+		if err != nil {
+			deck.ErrorfA("Invalid %s %q, using no jitter:\n%v", name, v, err).With(eventID(cablib.EvtErrConfig)).Go()
+			continue
+		}
+		if s.Jitter == nil {
+			s.Jitter = make(map[jobs.Job]time.Duration)
+		}
+		s.Jitter[j] = d
+	}
+	for _, j := range jobs.Jobs {
+		name := string(j) + "Interval"
+		if v, _, err := k.GetStringValue(name); err == nil {
+			if d, err := jobIntervals[j].parse(v); err == nil {
+				s.Intervals[j] = d
+			} else {
+				deck.ErrorfA("Invalid %s %q, using default %v:\n%v", name, v, s.Intervals[j], err).With(eventID(cablib.EvtErrConfig)).Go()
+			}
+		}
+	}
+	if v, _, err := k.GetStringValue("AukeraInterval"); err == nil {
+		if d, err := aukeraInterval.parse(v); err == nil {
+			s.AukeraInterval = d
+		} else {
+			deck.ErrorfA("Invalid AukeraInterval %q, using default %v:\n%v", v, s.AukeraInterval, err).With(eventID(cablib.EvtErrConfig)).Go()
+		}
+	}
+	if i, _, err := k.GetIntegerValue("ReconcileHidden"); err == nil {
+		s.ReconcileHidden = i
+	}
+	if r, _, err := k.GetStringValue("Ring"); err == nil {
+		s.Ring = r
+	}
+	if m, _, err := k.GetStringsValue("RingDeferrals"); err == nil {
+		for _, v := range m {
+			ring, category, d, err := policy.ParseDeferral(v)
+			if err != nil {
+				deck.ErrorfA("Ignoring invalid RingDeferrals entry:\n%v", err).With(eventID(cablib.EvtErrConfig)).Go()
+				continue
+			}
+			if s.Rings == nil {
+				s.Rings = make(map[string]policy.Ring)
+			}
+			r := s.Rings[ring]
+			if r.Deferral == nil {
+				r = policy.Ring{Name: ring, Deferral: make(map[string]time.Duration)}
+			}
+			r.Deferral[category] = d
+			s.Rings[ring] = r
+		}
+	}
+	if m, _, err := k.GetStringsValue("RingShares"); err == nil {
+		var shares []policy.RingShare
+		total := 0
+		for _, v := range m {
+			r, err := policy.ParseRingShare(v)
+			if err != nil {
+				deck.ErrorfA("Ignoring invalid RingShares entry:\n%v", err).With(eventID(cablib.EvtErrConfig)).Go()
+				continue
+			}
+			shares = append(shares, r)
+			total += r.Percent
+		}
+		if total > 100 {
+			deck.ErrorfA("Ignoring RingShares, which add up to %d%%, more than 100%%.", total).With(eventID(cablib.EvtErrConfig)).Go()
+		} else {
+			s.RingShares = shares
+		}
+	}
 
 	return nil
 }
@@ -280,6 +626,21 @@ func initMetrics() error {
 		return fmt.Errorf("unable to create enforcementWatcherFailures metric: %v", err)
 	}
 
+	invalidEnforcementFiles, err = metrics.NewInt(cablib.MetricRoot+"invalidEnforcementFiles", cablib.MetricSvc)
+	if err != nil {
+		return fmt.Errorf("unable to initialize invalidEnforcementFiles metric: %v", err)
+	}
+
+	hiddenDriftCount, err = metrics.NewInt(cablib.MetricRoot+"hiddenDriftCount", cablib.MetricSvc)
+	if err != nil {
+		return fmt.Errorf("unable to initialize hiddenDriftCount metric: %v", err)
+	}
+
+	remoteEnforcementSuccess, err = metrics.NewBool(cablib.MetricRoot+"remoteEnforcementSuccess", cablib.MetricSvc)
+	if err != nil {
+		return fmt.Errorf("unable to initialize remoteEnforcementSuccess metric: %v", err)
+	}
+
 	// string metrics
 	installHResult, err = metrics.NewString(cablib.MetricRoot+"installHResult", cablib.MetricSvc)
 	if err != nil {
@@ -309,25 +670,135 @@ func setRebootMetric() {
 	}
 }
 
-func enforce() error {
+// enforcementTrust returns the keys and mode used to verify enforcement files.
+// Keys that cannot be parsed are skipped, which can only reject more files.
+func (s *Settings) enforcementTrust() enforcement.Trust {
+	t := enforcement.Trust{RequireSigned: s.RequireSignedEnforcement == 1}
+	for _, k := range s.EnforcementKeys {
+		pub, err := enforcement.ParsePublicKey(k)
+		if err != nil {
+			deck.ErrorfA("Ignoring enforcement key:\n%v", err).With(eventID(cablib.EvtErrConfig)).Go()
+			continue
+		}
+		t.Keys = append(t.Keys, pub)
+	}
+	return t
+}
+
+// getEnforcements reads the enforcement files, reporting each file that was
+// skipped because it could not be used and each conflict between files.
+func getEnforcements() (enforcement.Enforcements, error) {
+	e, fileErrs, err := enforcement.Get()
+	if err != nil {
+		return e, err
+	}
+	for _, fe := range fileErrs {
+		deck.ErrorfA("Ignoring invalid enforcement file:\n%v", fe).With(eventID(cablib.EvtErrEnforcement)).Go()
+	}
+	for _, c := range e.Conflicts {
+		deck.WarningfA("Conflicting enforcement rules: %s", c).With(eventID(cablib.EvtErrEnforcement)).Go()
+	}
+	if err := invalidEnforcementFiles.Set(int64(len(fileErrs))); err != nil {
+		deck.ErrorfA("Error posting metric:\n%v", err).With(eventID(cablib.EvtErrMetricReport)).Go()
+	}
+	return e, nil
+}
+
+// enforcementInstalls returns the installs enforce runs for e at now: one for
+// the required updates past their install-by deadline, and one for the other
+// required updates and selectors. Either is nil if there is nothing to
+// install. The required updates deferred to the maintenance window until their
+// install-by deadline are also returned. During a blackout, only the required
+// updates that override it are installed, whatever their deadline.
+func enforcementInstalls(e enforcement.Enforcements, now time.Time) (overdue, required *installCmd, deferred []string) {
+	req, od, deferred := e.Due(now)
+	selector := e.RequiredSelector()
+	override := false
+	if p, ok := activeBlackout(now); ok {
+		req, od = e.Emergency(req), e.Emergency(od)
+		selector = enforcement.Selector{}
+		override = true
+		deck.InfofA("Blackout period %s is in effect, installing only required updates that override it.", p).With(eventID(cablib.EvtBlackout)).Go()
+	}
+	if len(od) > 0 {
+		overdue = &installCmd{kbs: strings.Join(od, ","), overdue: true, overrideBlackout: override, hidden: e.HiddenSelector()}
+	}
+	if len(req) > 0 || !selector.Empty() {
+		required = &installCmd{kbs: strings.Join(req, ","), overrideBlackout: override, selector: selector, hidden: e.HiddenSelector()}
+	}
+	return overdue, required, deferred
+}
+
+// enforceRollback rolls back the updates to uninstall in e. A rollback, like
+// an install, waits for the end of a blackout period, except for the updates
+// that override it, whose reboot is not held either.
+func enforceRollback(e enforcement.Enforcements, now time.Time) error {
+	ids := e.Uninstall
+	override := false
+	if p, ok := activeBlackout(now); ok {
+		ids = e.Emergency(e.Uninstall)
+		override = true
+		var held []string
+		for _, id := range e.Uninstall {
+			if len(e.Emergency([]string{id})) == 0 {
+				held = append(held, id)
+			}
+		}
+		if len(held) > 0 {
+			deck.InfofA("Blackout period %s is in effect, holding the rollback of: %s", p, strings.Join(held, ", ")).With(eventID(cablib.EvtBlackout)).Go()
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return rollback(ids, override)
+}
+
+// enforce applies the active enforcement rules. It returns when a scheduled
+// rule next becomes active or lapses, or the zero time if none will.
+func enforce() (time.Time, error) {
 	ctx := context.Background()
-	updates, err := enforcement.Get()
+	updates, err := getEnforcements()
 	if err != nil {
-		return fmt.Errorf("error retrieving required updates: %v", err)
+		return time.Time{}, fmt.Errorf("error retrieving required updates: %v", err)
 	}
 	if err := enforcedUpdateCount.Set(int64(len(updates.Required))); err != nil {
 		deck.ErrorfA("Error posting metric:\n%v", err).With(eventID(cablib.EvtErrMetricReport)).Go()
 	}
 	var failures error
-	if len(updates.Required) > 0 {
-		i := installCmd{kbs: strings.Join(updates.Required, ",")}
-		if err := i.installUpdates(ctx); err != nil {
+	if err := enforceRollback(updates, time.Now()); err != nil {
+		failures = fmt.Errorf("error rolling back updates: %v", err)
+		deck.ErrorA(failures).With(eventID(cablib.EvtErrUninstallFailure)).Go()
+	}
+	overdue, required, deferred := enforcementInstalls(updates, time.Now())
+	if overdue != nil {
+		deck.InfofA("Install-by deadline reached, forcing install of required updates: %s", strings.ReplaceAll(overdue.kbs, ",", ", ")).With(eventID(cablib.EvtInstall)).Go()
+		if err := overdue.installUpdates(ctx); err != nil {
+			failures = fmt.Errorf("error enforcing overdue required updates: %v", err)
+			deck.ErrorA(failures).With(eventID(cablib.EvtErrInstallFailure)).Go()
+		}
+	}
+	if required != nil {
+		if err := required.installUpdates(ctx); err != nil {
 			failures = fmt.Errorf("error enforcing required updates: %v", err)
 			deck.ErrorA(failures).With(eventID(cablib.EvtErrInstallFailure)).Go()
 		}
 	}
-	if len(updates.Hidden) > 0 {
-		if err := hide(NewKBSetFromSlice(updates.Hidden)); err != nil {
+	if len(deferred) > 0 {
+		deck.InfofA("Deferring required updates to the maintenance window until their install-by deadline: %s", strings.Join(deferred, ", ")).With(eventID(cablib.EvtUpdateSkip)).Go()
+	}
+	if config.ReconcileHidden == 1 {
+		drift, err := reconcileHidden(updates)
+		if err != nil {
+			failures = fmt.Errorf("error reconciling hidden updates: %v", err)
+			deck.ErrorA(failures).With(eventID(cablib.EvtErrHide)).Go()
+		} else if err := hiddenDriftCount.Set(int64(len(drift))); err != nil {
+			deck.ErrorfA("Error posting hiddenDriftCount metric:\n%v", err).With(eventID(cablib.EvtErrMetricReport)).Go()
+		}
+		return updates.NextChange, failures
+	}
+	if len(updates.Hidden) > 0 || !updates.HiddenSelector().Empty() {
+		if err := hide(NewKBSetFromSlice(updates.Hidden), updates.HiddenSelector()); err != nil {
 			failures = fmt.Errorf("error hiding updates: %v", err)
 			deck.ErrorA(failures).With(eventID(cablib.EvtErrHide)).Go()
 		}
@@ -338,15 +809,125 @@ func enforce() error {
 			deck.ErrorA(failures).With(eventID(cablib.EvtErrHide)).Go()
 		}
 	}
-	return failures
+	return updates.NextChange, failures
+}
+
+// armRuleTimer sets timer to fire at next, or stops it if next is zero.
+func armRuleTimer(timer *time.Timer, next time.Time) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	if !next.IsZero() {
+		timer.Reset(time.Until(next))
+	}
+}
+
+// remoteFetchTimeout bounds a fetch of the remote enforcement document, so
+// that a stalled server cannot hold up the service.
+const remoteFetchTimeout = 2 * time.Minute
+
+// remoteFetch is the outcome of a fetch of the remote enforcement document.
+type remoteFetch struct {
+	start   time.Time
+	changed bool
+	err     error
+}
+
+// fetchRemoteEnforcement refreshes the cached remote enforcement document and
+// delivers the outcome on done, unless ctx is done first. Errors are logged.
+// It is run in its own goroutine, so that the service keeps running its other
+// jobs during the fetch.
+func fetchRemoteEnforcement(ctx context.Context, done chan<- remoteFetch) {
+	f := remoteFetch{start: time.Now()}
+	fetchCtx, cancel := context.WithTimeout(ctx, remoteFetchTimeout)
+	defer cancel()
+	r := &enforcement.Remote{URL: config.EnforcementURL, Client: &http.Client{Timeout: remoteFetchTimeout}}
+	f.changed, f.err = r.Fetch(fetchCtx)
+	if e := remoteEnforcementSuccess.Set(f.err == nil); e != nil {
+		deck.ErrorfA("Error posting remoteEnforcementSuccess metric:\n%v", e).With(eventID(cablib.EvtErrMetricReport)).Go()
+	}
+	if f.err != nil {
+		deck.ErrorfA("Error fetching remote enforcement from %q, keeping the cached copy:\n%v", config.EnforcementURL, f.err).With(eventID(cablib.EvtErrEnforcement)).Go()
+	} else if f.changed {
+		deck.InfofA("Remote enforcement from %q changed.", config.EnforcementURL).With(eventID(cablib.EvtEnforcementChange)).Go()
+	}
+	select {
+	case done <- f:
+	case <-ctx.Done():
+	}
+}
+
+// windowInstall installs the updates due in a maintenance window: the selected
+// system updates, then the required updates whose install-by deadline has not
+// been reached yet.
+func windowInstall(ctx context.Context) error {
+	i := installCmd{Interactive: false}
+	err := i.installUpdates(ctx)
+	e, eerr := getEnforcements()
+	if eerr != nil {
+		deck.ErrorfA("Error retrieving required updates:\n%v", eerr).With(eventID(cablib.EvtErrEnforcement)).Go()
+		return err
+	}
+	if _, _, deferred := e.Due(time.Now()); len(deferred) > 0 {
+		r := installCmd{kbs: strings.Join(deferred, ",")}
+		if rerr := r.installUpdates(ctx); rerr != nil {
+			deck.ErrorfA("Error installing required updates ahead of their deadline:\n%v", rerr).With(eventID(cablib.EvtErrInstallFailure)).Go()
+		}
+	}
+	return err
+}
+
+// runEnforcementWatcher watches the enforcement directory until ctx is done,
+// restarting the watcher after a delay whenever it fails. The default
+// enforcement schedule still applies while it is down.
+func runEnforcementWatcher(ctx context.Context, changes chan<- enforcement.Change) {
+	for {
+		err := enforcement.Watcher(ctx, changes)
+		if ctx.Err() != nil {
+			return
+		}
+		deck.ErrorfA("Enforcement config watcher failed; relying on default enforcement schedule:\n%v", err).With(eventID(cablib.EvtErrEnforcement)).Go()
+		if err := enforcementWatcherFailures.Increment(); err != nil {
+			deck.ErrorfA("unable to increment enforcementWatcherFailures metric: %v", err).With(eventID(cablib.EvtErrMetricReport)).Go()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(15 * time.Minute):
+		}
+	}
+}
+
+// windowInstallScheduled installs updates in a window of the built-in
+// schedule, unless the window closed while the service was busy. The run is
+// recorded as a run of the install job of t.
+func windowInstallScheduled(ctx context.Context, w schedule.Window, t *jobs.Timer) {
+	if !w.Contains(time.Now()) {
+		deck.InfofA("Maintenance window %s closed before installation could start.", w).With(eventID(cablib.EvtUpdateSkip)).Go()
+		return
+	}
+	deck.InfofA("Maintenance window %s open: Starting installation process.", w).With(eventID(cablib.EvtInstall)).Go()
+	start := time.Now()
+	err := windowInstall(ctx)
+	jobDone(t, start, err)
+	if err != nil {
+		deck.ErrorfA("Error installing system updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
+	}
+	if e := updateInstallSuccess.Set(err == nil); e != nil {
+		deck.ErrorfA("Error posting updateInstallSuccess metric:\n%v", e).With(eventID(cablib.EvtErrMetricReport)).Go()
+	}
+	setRebootMetric()
 }
 
 func initDriverExclusion() error {
-	updates, err := enforcement.Get()
+	updates, err := getEnforcements()
 	if err != nil {
 		return fmt.Errorf("error retrieving required updates: %v", err)
 	}
-	excludedDrivers.set(updates.ExcludedDrivers)
+	excludedDrivers.set(updates)
 	return nil
 }
 
@@ -369,25 +950,67 @@ func runMainLoop() error {
 	defer t.stop()
 
 	// Run filesystem watcher for required updates configuration.
-	enforcedFile := make(chan string)
-	go func() {
-		for {
-			if err := enforcement.Watcher(enforcedFile); err == nil {
-				deck.ErrorfA("failed to initialize enforcement config watcher; relying on default enforcement schedule: %v", err).With(eventID(cablib.EvtErrEnforcement)).Go()
-			}
-			if err := enforcementWatcherFailures.Increment(); err != nil {
-				deck.ErrorfA("unable to increment enforcementWatcherFailures metric: %v", err).With(eventID(cablib.EvtErrMetricReport)).Go()
-			}
-			time.Sleep(15 * time.Minute)
-		}
-	}()
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	enforcedFiles := make(chan enforcement.Change)
+	go runEnforcementWatcher(watchCtx, enforcedFiles)
+
+	// The remote enforcement document is fetched by the Remote job, scheduled
+	// like the other jobs; the cached copy applies until then.
+	remoteFetched := make(chan remoteFetch)
+	if config.EnforcementURL == "" {
+		t.Remote.Stop()
+	}
 
-	if config.AukeraEnabled == 1 {
+	// Re-evaluate enforcement when a scheduled rule becomes active or lapses.
+	ruleTimer := time.NewTimer(time.Hour)
+	defer ruleTimer.Stop()
+	if e, err := getEnforcements(); err != nil {
+		deck.ErrorfA("Error retrieving enforcement schedule:\n%v", err).With(eventID(cablib.EvtErrEnforcement)).Go()
+		armRuleTimer(ruleTimer, time.Time{})
+	} else {
+		armRuleTimer(ruleTimer, e.NextChange)
+	}
+
+	// Open and close maintenance windows from the built-in schedule, if used.
+	windowTimer := time.NewTimer(time.Hour)
+	defer windowTimer.Stop()
+	var sched *schedule.Schedule
+	var windowEvt schedule.Event
+	armRuleTimer(windowTimer, time.Time{})
+
+	// Install on the day set after Patch Tuesday, with the default interval.
+	patchTimer := time.NewTimer(time.Hour)
+	defer patchTimer.Stop()
+	patch := config.patchDelay()
+	armRuleTimer(patchTimer, time.Time{})
+
+	switch {
+	case config.AukeraEnabled == 1:
 		deck.InfoA("Host configured to use Aukera. Ignoring default timer.").With(eventID(cablib.EvtMisc)).Go()
+		if config.Schedule != "" {
+			deck.WarningfA("Ignoring Schedule %q, Aukera maintenance windows take precedence.", config.Schedule).With(eventID(cablib.EvtErrConfig)).Go()
+		}
 		t.Default.Stop()
-	} else {
+	case config.Schedule != "":
+		s, err := schedule.New(config.Schedule, config.Duration, config.TimeZone)
+		if err != nil {
+			deck.ErrorfA("Invalid maintenance window schedule, using default update interval:\n%v", err).With(eventID(cablib.EvtErrConfig)).Go()
+			t.Aukera.Stop()
+			break
+		}
+		sched = s
+		deck.InfofA("Using maintenance window schedule %s.", sched).With(eventID(cablib.EvtMisc)).Go()
+		t.Default.Stop()
+		t.Aukera.Stop()
+		windowEvt = sched.Start(time.Now())
+		armWindowTimer(windowTimer, windowEvt)
+	default:
 		deck.InfoA("Using default update interval.").With(eventID(cablib.EvtMisc)).Go()
 		t.Aukera.Stop()
+		if patch != nil {
+			armPatchTimer(patchTimer, patch, time.Now())
+		}
 	}
 
 	if config.InstallVirusDefs == 0 {
@@ -397,12 +1020,20 @@ func runMainLoop() error {
 	if config.InstallDrivers == 0 {
 		t.Driver.Stop()
 	}
+	t.logJobs()
+	if r := config.deploymentRing(); r.Name != "" {
+		deck.InfofA("Deployment ring %q, deferring updates by category: %v", r.Name, r.Deferral).With(eventID(cablib.EvtMisc)).Go()
+	}
+	if patch != nil {
+		deck.InfofA("Holding updates until %s.", patch).With(eventID(cablib.EvtMisc)).Go()
+	}
 
 	for {
 		select {
 		case <-t.Default.C:
-			i := installCmd{Interactive: false}
-			err := i.installUpdates(ctx)
+			start := time.Now()
+			err := windowInstall(ctx)
+			jobDone(t.Default, start, err)
 			if err != nil {
 				deck.ErrorfA("Error installing system updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
 			}
@@ -410,6 +1041,27 @@ func runMainLoop() error {
 				deck.ErrorfA("Error posting metric:\n%v", e).With(eventID(cablib.EvtErrMetricReport)).Go()
 			}
 			setRebootMetric()
+		case <-patchTimer.C:
+			deck.InfofA("Installing updates held until %s.", patch).With(eventID(cablib.EvtInstall)).Go()
+			start := time.Now()
+			err := windowInstall(ctx)
+			jobDone(t.Default, start, err)
+			if err != nil {
+				deck.ErrorfA("Error installing system updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
+			}
+			if e := updateInstallSuccess.Set(err == nil); e != nil {
+				deck.ErrorfA("Error posting updateInstallSuccess metric:\n%v", e).With(eventID(cablib.EvtErrMetricReport)).Go()
+			}
+			setRebootMetric()
+			armPatchTimer(patchTimer, patch, time.Now())
+		case <-windowTimer.C:
+			if windowEvt.Open {
+				windowInstallScheduled(ctx, windowEvt.Window, t.Default)
+			} else {
+				deck.InfofA("Maintenance window %s closed.", windowEvt.Window).With(eventID(cablib.EvtMisc)).Go()
+			}
+			windowEvt = sched.Following(windowEvt)
+			armWindowTimer(windowTimer, windowEvt)
 		case <-t.Aukera.C:
 			s, err := client.Label(int(config.AukeraPort), config.AukeraName)
 			if err != nil {
@@ -423,6 +1075,10 @@ func runMainLoop() error {
 				deck.ErrorfA("Aukera maintenance window label %q not found, skipping update check...", config.AukeraName).With(eventID(cablib.EvtErrMaintWindow)).Go()
 				break
 			}
+			if at := s[0].Opens.Add(aukeraSplay(s[0])); s[0].State == "open" && time.Now().Before(at) {
+				deck.InfofA("Maintenance window open, waiting for the host splay until %s.", at.Format(time.RFC3339)).With(eventID(cablib.EvtMisc)).Go()
+				break
+			}
 			if config.ActiveHoursEnabled == 1 {
 				deck.InfofA("Active Hours enabled: checking for active_hours schedule.").With(eventID(cablib.EvtMisc)).Go()
 				ah, err := client.Label(int(config.AukeraPort), `active_hours`)
@@ -446,8 +1102,9 @@ func runMainLoop() error {
 				// within the standard `cabbie` maintenance window, we'll install updates.
 				if trimmedOpen.Before(now) && trimmedClose.After(now) && ((today >= maintOpenDay) && (today <= maintCloseDay)) {
 					deck.InfofA("Active Hours + Maintenance window open: Starting installation process.").With(eventID(cablib.EvtInstall)).Go()
-					i := installCmd{Interactive: false}
-					err := i.installUpdates(ctx)
+					start := time.Now()
+					err := windowInstall(ctx)
+					jobDone(t.Default, start, err)
 					if err != nil {
 						deck.ErrorfA("Error installing system updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
 					}
@@ -462,8 +1119,9 @@ func runMainLoop() error {
 				// as long as the standard `cabbie` maintenance window is open.
 				if s[0].State == "open" {
 					deck.InfofA("Maintenance window open: Starting installation process.").With(eventID(cablib.EvtInstall)).Go()
-					i := installCmd{Interactive: false}
-					err := i.installUpdates(ctx)
+					start := time.Now()
+					err := windowInstall(ctx)
+					jobDone(t.Default, start, err)
 					if err != nil {
 						deck.ErrorfA("Error installing system updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
 					}
@@ -474,7 +1132,9 @@ func runMainLoop() error {
 				}
 			}
 		case <-t.List.C:
-			requiredUpdates, optionalUpdates, err := listUpdates(false, false)
+			start := time.Now()
+			required, optional, err := listUpdates(false)
+			jobDone(t.List, start, err)
 			if e := listUpdateSuccess.Set(err == nil); e != nil {
 				deck.ErrorfA("Error posting listUpdateSuccess metric:\n%v", e).With(eventID(cablib.EvtErrMetricReport)).Go()
 			}
@@ -482,6 +1142,7 @@ func runMainLoop() error {
 				deck.ErrorfA("Error getting the list of updates:\n%v", err).With(eventID(cablib.EvtErrQueryFailure)).Go()
 				break
 			}
+			requiredUpdates, optionalUpdates := updateLabels(required, false), updateLabels(optional, false)
 			if err := requiredUpdateCount.Set(int64(len(requiredUpdates))); err != nil {
 				deck.ErrorfA("Error posting requiredUpdateCount metric:\n%v", err).With(eventID(cablib.EvtErrMetricReport)).Go()
 			}
@@ -510,8 +1171,10 @@ func runMainLoop() error {
 				}
 			}
 		case <-t.Virus.C:
+			start := time.Now()
 			i := installCmd{Interactive: false, virusDef: true}
 			err := i.installUpdates(ctx)
+			jobDone(t.Virus, start, err)
 			if e := virusUpdateSuccess.Set(err == nil); e != nil {
 				deck.ErrorfA("Error posting virusUpdateSuccess metric:\n%v", e).With(eventID(cablib.EvtErrMetricReport)).Go()
 			}
@@ -520,8 +1183,10 @@ func runMainLoop() error {
 				break
 			}
 		case <-t.Driver.C:
+			start := time.Now()
 			i := installCmd{Interactive: false, drivers: true}
 			err := i.installUpdates(ctx)
+			jobDone(t.Driver, start, err)
 			if e := driverUpdateSuccess.Set(err == nil); e != nil {
 				deck.ErrorfA("Error posting driverUpdateSuccess metric:\n%v", e).With(eventID(cablib.EvtErrMetricReport)).Go()
 			}
@@ -529,15 +1194,41 @@ func runMainLoop() error {
 				deck.ErrorfA("Error installing drivers:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
 			}
 			setRebootMetric()
-		case file := <-enforcedFile:
-			deck.InfofA("Enforcement triggered by change in file %q.", file).With(eventID(cablib.EvtEnforcementChange)).Go()
-			if err := enforce(); err != nil {
+		case c := <-enforcedFiles:
+			deck.InfofA("Enforcement triggered by change in files %v.", c).With(eventID(cablib.EvtEnforcementChange)).Go()
+			next, err := enforce()
+			if err != nil {
+				deck.ErrorfA("Error enforcing one or more updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
+			}
+			armRuleTimer(ruleTimer, next)
+		case <-ruleTimer.C:
+			deck.InfoA("Enforcement triggered by a scheduled rule becoming active or expiring.").With(eventID(cablib.EvtEnforcementChange)).Go()
+			next, err := enforce()
+			if err != nil {
 				deck.ErrorfA("Error enforcing one or more updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
 			}
+			armRuleTimer(ruleTimer, next)
+		case <-t.Remote.C:
+			// The timer fires again only once the fetch is recorded as done.
+			go fetchRemoteEnforcement(watchCtx, remoteFetched)
+		case f := <-remoteFetched:
+			jobDone(t.Remote, f.start, f.err)
+			if !f.changed {
+				break
+			}
+			next, err := enforce()
+			if err != nil {
+				deck.ErrorfA("Error enforcing one or more updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
+			}
+			armRuleTimer(ruleTimer, next)
 		case <-t.Enforcement.C:
-			if err := enforce(); err != nil {
+			start := time.Now()
+			next, err := enforce()
+			jobDone(t.Enforcement, start, err)
+			if err != nil {
 				deck.ErrorfA("Error enforcing one or more updates:\n%v", err).With(eventID(cablib.EvtErrInstallFailure)).Go()
 			}
+			armRuleTimer(ruleTimer, next)
 		case <-rebootEvent:
 			go func() {
 				if !(rebootActive) {
@@ -553,7 +1244,7 @@ func runMainLoop() error {
 						return
 					}
 					deck.InfofA("Reboot time is %s", t.String()).With(eventID(cablib.EvtMisc)).Go()
-					if err := cablib.SystemReboot(t); err != nil {
+					if err := cablib.SystemReboot(t, rebootHold); err != nil {
 						deck.ErrorfA("SystemReboot() error:\n%v", err).With(eventID(cablib.EvtErrPowerMgmt)).Go()
 					}
 					rebootActive = false
@@ -655,6 +1346,7 @@ func main() {
 	if err = config.regLoad(cablib.RegPath); err != nil {
 		deck.ErrorfA("Failed to load Cabbie config, using defaults:\n%v\nError:%v", config, err).With(eventID(cablib.EvtErrConfig)).Go()
 	}
+	enforcement.SetTrust(config.enforcementTrust())
 
 	// If a profiling port is specified, start an HTTP server
 	if config.PprofPort != 0 {
@@ -696,9 +1388,13 @@ func main() {
 	subcommands.Register(&hideCmd{}, "Update management")
 	subcommands.Register(&historyCmd{}, "Update management")
 	subcommands.Register(&installCmd{Interactive: true}, "Update management")
+	subcommands.Register(&planCmd{}, "Update management")
 	subcommands.Register(&listCmd{}, "Update management")
+	subcommands.Register(&uninstallCmd{}, "Update management")
+	subcommands.Register(&enforcementCmd{}, "Enforcement management")
 	subcommands.Register(&rebootCmd{}, "Reboot management")
 	subcommands.Register(&serviceCmd{}, "Service registration management")
+	subcommands.Register(&statusCmd{}, "Service management")
 	subcommands.Register(&wsusCmd{}, "WSUS management")
 
 	if *runInDebug {
@@ -16,7 +16,10 @@ package main
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/cabbie/jobs"
+	"github.com/google/cabbie/policy"
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/sys/windows/registry"
 )
@@ -94,3 +97,205 @@ func TestRegLoadRequiredCategories(t *testing.T) {
 		t.Errorf("testconfig.regLoad(%s) = %v, want %v", testPath, testconfig, expected)
 	}
 }
+
+func TestRegLoadSchedule(t *testing.T) {
+	// Setup
+	if err := createTestKeys(); err != nil {
+		t.Fatal(err)
+	}
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, testPath, registry.SET_VALUE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, v := range map[string]string{"Schedule": "0 0 2 * * SAT", "Duration": "90m", "TimeZone": "Europe/Zurich"} {
+		if err := k.SetStringValue(n, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	k.Close()
+	defer cleanupTestKey()
+
+	expected := newSettings()
+	expected.Schedule = "0 0 2 * * SAT"
+	expected.Duration = 90 * time.Minute
+	expected.TimeZone = "Europe/Zurich"
+	testconfig := newSettings()
+	// End Setup
+	if err := testconfig.regLoad(testPath); err != nil {
+		t.Error(err)
+	}
+	if !(cmp.Equal(testconfig, expected)) {
+		t.Errorf("testconfig.regLoad(%s) = %v, want %v", testPath, testconfig, expected)
+	}
+}
+
+func TestIntervalParse(t *testing.T) {
+	i := intervalSetting{def: time.Hour, min: 15 * time.Minute, max: 24 * time.Hour}
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"2h", 2 * time.Hour, false},
+		{"15m", 15 * time.Minute, false},
+		{"24h", 24 * time.Hour, false},
+		{"5m", 0, true},
+		{"48h", 0, true},
+		{"2", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := i.parse(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parse(%q) = %v, %v, want %v, error: %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRegLoadIntervals(t *testing.T) {
+	// Setup
+	if err := createTestKeys(); err != nil {
+		t.Fatal(err)
+	}
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, testPath, registry.SET_VALUE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, v := range map[string]string{"ListInterval": "12h", "VirusInterval": "2h", "DriverInterval": "1m", "RemoteInterval": "30m", "AukeraInterval": "10m"} {
+		if err := k.SetStringValue(n, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	k.Close()
+	defer cleanupTestKey()
+
+	expected := newSettings()
+	expected.Intervals[jobs.List] = 12 * time.Hour
+	expected.Intervals[jobs.Virus] = 2 * time.Hour
+	// DriverInterval is below its minimum and keeps its default.
+	expected.Intervals[jobs.Remote] = 30 * time.Minute
+	expected.AukeraInterval = 10 * time.Minute
+	testconfig := newSettings()
+	// End Setup
+	if err := testconfig.regLoad(testPath); err != nil {
+		t.Error(err)
+	}
+	if !(cmp.Equal(testconfig, expected)) {
+		t.Errorf("testconfig.regLoad(%s) = %v, want %v", testPath, testconfig, expected)
+	}
+}
+
+func TestRegLoadSpread(t *testing.T) {
+	// Setup
+	if err := createTestKeys(); err != nil {
+		t.Fatal(err)
+	}
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, testPath, registry.SET_VALUE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, v := range map[string]string{"Splay": "20m", "ListJitter": "5m", "VirusJitter": "48h"} {
+		if err := k.SetStringValue(n, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	k.Close()
+	defer cleanupTestKey()
+
+	expected := newSettings()
+	expected.Splay = 20 * time.Minute
+	// VirusJitter is above its maximum and is ignored.
+	expected.Jitter = map[jobs.Job]time.Duration{jobs.List: 5 * time.Minute}
+	testconfig := newSettings()
+	// End Setup
+	if err := testconfig.regLoad(testPath); err != nil {
+		t.Error(err)
+	}
+	if !(cmp.Equal(testconfig, expected)) {
+		t.Errorf("testconfig.regLoad(%s) = %v, want %v", testPath, testconfig, expected)
+	}
+}
+
+func TestRegLoadRings(t *testing.T) {
+	// Setup
+	if err := createTestKeys(); err != nil {
+		t.Fatal(err)
+	}
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, testPath, registry.SET_VALUE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetStringValue("Ring", "early"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetStringsValue("RingDeferrals", []string{"canary:Security Updates=0", "early:Security Updates=3", "early:Critical Updates=5", "broad=14"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetStringsValue("RingShares", []string{"canary=5", "early=25", "broad=200"}); err != nil {
+		t.Fatal(err)
+	}
+	k.Close()
+	defer cleanupTestKey()
+
+	day := 24 * time.Hour
+	expected := newSettings()
+	expected.Ring = "early"
+	// "broad=14" and "broad=200" are invalid and ignored.
+	expected.Rings = map[string]policy.Ring{
+		"canary": {Name: "canary", Deferral: map[string]time.Duration{"Security Updates": 0}},
+		"early":  {Name: "early", Deferral: map[string]time.Duration{"Security Updates": 3 * day, "Critical Updates": 5 * day}},
+	}
+	expected.RingShares = []policy.RingShare{{Ring: "canary", Percent: 5}, {Ring: "early", Percent: 25}}
+	testconfig := newSettings()
+	// End Setup
+	if err := testconfig.regLoad(testPath); err != nil {
+		t.Error(err)
+	}
+	if diff := cmp.Diff(expected, testconfig); diff != "" {
+		t.Errorf("testconfig.regLoad(%s) returned unexpected diff (-want +got):\n%s", testPath, diff)
+	}
+	if got := testconfig.deploymentRing(); got.Name != "early" {
+		t.Errorf("deploymentRing() = %q, want %q", got.Name, "early")
+	}
+}
+
+func TestRegLoadPatchTuesday(t *testing.T) {
+	for _, tt := range []struct {
+		days     uint32
+		wantDays int
+	}{
+		{7, 7},
+		{0, 0},
+		// More than schedule.MaxPatchDays is ignored.
+		{40, -1},
+	} {
+		// Setup
+		if err := createTestKeys(); err != nil {
+			t.Fatal(err)
+		}
+		k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, testPath, registry.SET_VALUE)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := k.SetDWordValue("InstallAfterPatchTuesdayDays", tt.days); err != nil {
+			t.Fatal(err)
+		}
+		if err := k.SetDWordValue("SkipPreviewReleases", 1); err != nil {
+			t.Fatal(err)
+		}
+		k.Close()
+
+		testconfig := newSettings()
+		// End Setup
+		if err := testconfig.regLoad(testPath); err != nil {
+			t.Error(err)
+		}
+		cleanupTestKey()
+		if testconfig.InstallAfterPatchTuesdayDays != tt.wantDays || testconfig.SkipPreviewReleases != 1 {
+			t.Errorf("testconfig.regLoad(%s) with %d days = %d days, SkipPreviewReleases %d, want %d days, 1", testPath, tt.days, testconfig.InstallAfterPatchTuesdayDays, testconfig.SkipPreviewReleases, tt.wantDays)
+		}
+		if got := testconfig.patchDelay(); (got != nil) != (tt.wantDays >= 0) {
+			t.Errorf("patchDelay() with %d days = %v, want a delay: %t", tt.wantDays, got, tt.wantDays >= 0)
+		}
+	}
+}
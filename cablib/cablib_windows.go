@@ -65,6 +65,42 @@ func GetRebootUpdates() ([]string, error) {
 	return kbs, nil
 }
 
+// SetHiddenUpdates records the UpdateIDs that Cabbie hid because of an
+// enforcement in the registry.
+func SetHiddenUpdates(ids []string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, RegPath, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	if len(ids) == 0 {
+		if err := k.DeleteValue(`HiddenUpdates`); err != nil && err != registry.ErrNotExist {
+			return err
+		}
+		return nil
+	}
+	return k.SetStringsValue(`HiddenUpdates`, ids)
+}
+
+// GetHiddenUpdates retrieves the UpdateIDs that Cabbie hid because of an
+// enforcement from the registry.
+func GetHiddenUpdates() ([]string, error) {
+	var ids []string
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, RegPath, registry.READ)
+	if err != nil {
+		return ids, err
+	}
+	defer k.Close()
+
+	ids, _, err = k.GetStringsValue(`HiddenUpdates`)
+	if err != nil && err != registry.ErrNotExist {
+		return ids, fmt.Errorf("unable to get updates hidden by enforcement: %v", err)
+	}
+
+	return ids, nil
+}
+
 // cleanRebootUpdatesValue clears the reboot-required update list from the registry.
 func cleanRebootUpdatesValue() error {
 	k, err := registry.OpenKey(registry.LOCAL_MACHINE, RegPath, registry.SET_VALUE)
@@ -159,12 +195,30 @@ func ClearRebootTime() error {
 }
 
 // SystemReboot initiates a restart when the set reboot time has passed. This should be called within a goroutine
-func SystemReboot(t time.Time) error {
+//
+// hold, if not nil, returns when a blackout in effect at the given time ends,
+// or the zero time if none is. The restart waits for the blackout to end,
+// and the reboot popup is shown again once it has.
+func SystemReboot(t time.Time, hold func(time.Time) time.Time) error {
 	time.Sleep(time.Until(t))
 
-	notification.RebootPopup(30).Push()
+	for {
+		if hold != nil {
+			if until := hold(time.Now()); !until.IsZero() {
+				time.Sleep(time.Until(until))
+				continue
+			}
+		}
+
+		notification.RebootPopup(30).Push()
 
-	time.Sleep(sleepWaitTime)
+		time.Sleep(sleepWaitTime)
+
+		// A blackout may have started while the popup was shown.
+		if hold == nil || hold(time.Now()).IsZero() {
+			break
+		}
+	}
 
 	if err := ClearRebootTime(); err != nil {
 		return fmt.Errorf("failed to clean up registry value %q: %v", rebootValue, err)
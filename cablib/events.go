@@ -64,6 +64,12 @@ const (
 	EvtMisc
 	// EvtDriverUpdateExcluded indicates a driver update was excluded.
 	EvtDriverUpdateExcluded
+	// EvtUninstall indicates that cabbie is uninstalling updates.
+	EvtUninstall
+	// EvtHiddenDrift indicates updates hidden outside of Cabbie enforcement.
+	EvtHiddenDrift
+	// EvtBlackout indicates that cabbie is holding changes during a blackout period.
+	EvtBlackout
 )
 
 /*
@@ -104,4 +110,6 @@ const (
 	EvtErrDriverExclusion
 	// EvtErrMisc indicates a miscellaneous internal error condition.
 	EvtErrMisc
+	// EvtErrUninstallFailure indicates a problem uninstalling updates.
+	EvtErrUninstallFailure
 )
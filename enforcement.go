@@ -0,0 +1,286 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"golang.org/x/net/context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"flag"
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/subcommands"
+)
+
+// enforcementCmd inspects the enforcement files.
+type enforcementCmd struct{}
+
+// ruleRecord is an effective enforcement rule and the files that set it.
+type ruleRecord struct {
+	Key     string
+	Value   string
+	Origins []enforcement.Origin
+	// InstallBy is the deadline of a required update, if it has one.
+	InstallBy *time.Time `json:",omitempty"`
+}
+
+// enforcementRecord is the machine-readable form of the effective enforcements.
+type enforcementRecord struct {
+	Dir          string
+	Rules        []ruleRecord
+	Conflicts    []enforcement.Conflict
+	InvalidFiles []string
+	// NextChange is when a scheduled rule next becomes active or lapses.
+	NextChange *time.Time `json:",omitempty"`
+}
+
+func (enforcementCmd) Name() string     { return "enforcement" }
+func (enforcementCmd) Synopsis() string { return "Inspect enforcement files." }
+func (enforcementCmd) Usage() string {
+	return fmt.Sprintf("%[1]s enforcement validate [<path>]\n%[1]s enforcement show [--format=text|json]\n"+
+		"%[1]s enforcement sign --key=<private key file> <file>...\n%[1]s enforcement keygen <private key file>\n", filepath.Base(os.Args[0]))
+}
+func (c *enforcementCmd) SetFlags(f *flag.FlagSet) {}
+
+func (c enforcementCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	switch flags.Arg(0) {
+	case "validate":
+		path := flags.Arg(1)
+		if path == "" {
+			path = enforcement.Dir()
+		}
+		n, err := validateEnforcement(os.Stdout, path)
+		if err != nil {
+			fmt.Printf("Failed to validate %q: %v\n", path, err)
+			return subcommands.ExitFailure
+		}
+		if n > 0 {
+			return subcommands.ExitFailure
+		}
+		return subcommands.ExitSuccess
+	case "show":
+		fs := flag.NewFlagSet("show", flag.ContinueOnError)
+		format := fs.String("format", formatText, "Output format, one of text or json.")
+		if err := fs.Parse(flags.Args()[1:]); err != nil {
+			return subcommands.ExitUsageError
+		}
+		if err := vetFormat(*format, formatText, formatJSON); err != nil {
+			fmt.Printf("%v\n%s\nUsage: %s\n", err, c.Synopsis(), c.Usage())
+			return subcommands.ExitUsageError
+		}
+		e, fileErrs, err := enforcement.Get()
+		if err != nil {
+			fmt.Printf("Failed to read enforcements: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		if err := showEnforcement(os.Stdout, *format, e, fileErrs); err != nil {
+			fmt.Printf("Failed to write enforcements: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		return subcommands.ExitSuccess
+	case "sign":
+		fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+		key := fs.String("key", "", "File holding the base64 ed25519 private key to sign with.")
+		if err := fs.Parse(flags.Args()[1:]); err != nil {
+			return subcommands.ExitUsageError
+		}
+		if *key == "" || fs.NArg() == 0 {
+			fmt.Printf("%s\nUsage: %s\n", c.Synopsis(), c.Usage())
+			return subcommands.ExitUsageError
+		}
+		if err := signEnforcement(os.Stdout, *key, fs.Args()); err != nil {
+			fmt.Printf("Failed to sign enforcement files: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		return subcommands.ExitSuccess
+	case "keygen":
+		path := flags.Arg(1)
+		if path == "" {
+			fmt.Printf("%s\nUsage: %s\n", c.Synopsis(), c.Usage())
+			return subcommands.ExitUsageError
+		}
+		if err := keygen(os.Stdout, path); err != nil {
+			fmt.Printf("Failed to generate an enforcement key: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		return subcommands.ExitSuccess
+	}
+	fmt.Printf("%s\nUsage: %s\n", c.Synopsis(), c.Usage())
+	return subcommands.ExitUsageError
+}
+
+// validateEnforcement checks the enforcement file at path, or every file in
+// it if path is a directory, and returns the number of problems written to w.
+func validateEnforcement(w io.Writer, path string) (int, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	files := []string{path}
+	if fi.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return 0, err
+		}
+		files = nil
+		for _, e := range entries {
+			if !e.IsDir() && filepath.Ext(e.Name()) != enforcement.SigExt {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+
+	n := 0
+	for _, f := range files {
+		problems := enforcement.Validate(f)
+		for _, p := range problems {
+			fmt.Fprintln(w, p)
+		}
+		if len(problems) == 0 {
+			fmt.Fprintf(w, "%s: OK\n", f)
+		}
+		n += len(problems)
+	}
+	return n, nil
+}
+
+// signEnforcement writes a detached signature next to each of the files,
+// using the private key in keyPath.
+func signEnforcement(w io.Writer, keyPath string, files []string) error {
+	b, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	k, err := enforcement.ParsePrivateKey(b)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := enforcement.Sign(k, f); err != nil {
+			return fmt.Errorf("error signing %q: %v", f, err)
+		}
+		fmt.Fprintf(w, "Signed %s: %s%s\n", f, f, enforcement.SigExt)
+	}
+	return nil
+}
+
+// keygen writes a new private key to path and the public key to pin in the
+// EnforcementKeys setting to w. An existing key is never overwritten.
+func keygen(w io.Writer, path string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(enforcement.MarshalPrivateKey(priv)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Wrote private key to %s.\nPublic key for EnforcementKeys: %s\n", path, enforcement.MarshalPublicKey(pub))
+	return nil
+}
+
+// effectiveRules lists the merged rules of e in file key order.
+func effectiveRules(e enforcement.Enforcements) []ruleRecord {
+	var rules []ruleRecord
+	add := func(key string, values []string) {
+		for _, v := range values {
+			rules = append(rules, ruleRecord{Key: key, Value: v, Origins: e.OriginsOf(key, v)})
+		}
+	}
+	add(enforcement.KeyRequired, e.Required)
+	for i := range rules {
+		if d, ok := e.InstallBy[rules[i].Value]; ok {
+			d := d
+			rules[i].InstallBy = &d
+		}
+	}
+	add(enforcement.KeyHidden, e.Hidden)
+	add(enforcement.KeyHiddenUpdateID, e.HiddenUpdateID)
+	add(enforcement.KeyUninstall, e.Uninstall)
+	add(enforcement.KeyRequiredCVEs, e.RequiredCVEs)
+	add(enforcement.KeyRequiredSeverity, e.RequiredSeverity)
+	add(enforcement.KeyRequiredCategories, e.RequiredCategories)
+	add(enforcement.KeyHiddenTitleRegex, e.HiddenTitleRegex)
+	for _, d := range e.ExcludedDrivers {
+		add(enforcement.KeyExcludedDrivers, []string{d.String()})
+	}
+	return rules
+}
+
+// showEnforcement writes the effective enforcements with the origin of every
+// rule, the conflicts resolved while merging, and the files that were skipped.
+func showEnforcement(w io.Writer, format string, e enforcement.Enforcements, fileErrs []enforcement.FileError) error {
+	r := enforcementRecord{Dir: enforcement.Dir(), Rules: effectiveRules(e), Conflicts: e.Conflicts, InvalidFiles: []string{}}
+	if r.Conflicts == nil {
+		r.Conflicts = []enforcement.Conflict{}
+	}
+	for _, fe := range fileErrs {
+		r.InvalidFiles = append(r.InvalidFiles, fe.Error())
+	}
+	if !e.NextChange.IsZero() {
+		r.NextChange = &e.NextChange
+	}
+	if format == formatJSON {
+		return writeRecords(w, format, r, nil, nil)
+	}
+
+	fmt.Fprintf(w, "Enforcement directory: %s\n", r.Dir)
+	fmt.Fprintln(w, "\nRules:")
+	if len(r.Rules) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	for _, rule := range r.Rules {
+		if rule.InstallBy != nil {
+			fmt.Fprintf(w, "  %s %s (install by %s)\n", rule.Key, rule.Value, formatTime(*rule.InstallBy))
+		} else {
+			fmt.Fprintf(w, "  %s %s\n", rule.Key, rule.Value)
+		}
+		for _, o := range rule.Origins {
+			fmt.Fprintf(w, "    from %s\n", o)
+		}
+	}
+	if len(r.Conflicts) > 0 {
+		fmt.Fprintln(w, "\nConflicts:")
+		for _, c := range r.Conflicts {
+			fmt.Fprintf(w, "  %s\n", c)
+			for _, k := range c.Keys {
+				for _, o := range c.Origins[k] {
+					fmt.Fprintf(w, "    %s from %s\n", k, o)
+				}
+			}
+		}
+	}
+	if r.NextChange != nil {
+		fmt.Fprintf(w, "\nNext scheduled change: %s\n", formatTime(*r.NextChange))
+	}
+	if len(r.InvalidFiles) > 0 {
+		fmt.Fprintln(w, "\nInvalid files (ignored):")
+		for _, f := range r.InvalidFiles {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/cabbie/updates"
+)
+
+// driverDate is the layout of driver exclusion dates.
+const driverDate = "2006-01-02"
+
+// Pattern matches a driver property. A pattern prefixed with "re:" is a
+// regular expression, otherwise it is a glob where * matches any run of
+// characters and ? matches one character. Both are case insensitive and
+// must match the whole value.
+type Pattern string
+
+func (p Pattern) regexp() (*regexp.Regexp, error) {
+	s := string(p)
+	if re, ok := cutPrefix(s, "re:"); ok {
+		return regexp.Compile("(?i)^(?:" + re + ")$")
+	}
+	glob := regexp.QuoteMeta(s)
+	glob = strings.ReplaceAll(glob, `\*`, ".*")
+	glob = strings.ReplaceAll(glob, `\?`, ".")
+	return regexp.Compile("(?i)^" + glob + "$")
+}
+
+// Match returns true if the pattern matches v. Invalid patterns match nothing.
+func (p Pattern) Match(v string) bool {
+	re, err := p.regexp()
+	return err == nil && re.MatchString(v)
+}
+
+// cutPrefix is strings.CutPrefix, which needs Go 1.20.
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+type driverField struct {
+	key, value string
+	// get returns the property of the update matched by a pattern. It is nil
+	// for dates.
+	get func(u *updates.Update) string
+	// date compares the driver date v to the date t of the criteria.
+	date func(v, t time.Time) bool
+}
+
+// fields lists the criteria of d with their file keys, patterns first.
+func (d DriverExclude) fields() []driverField {
+	return []driverField{
+		{"driver-class", d.DriverClass, func(u *updates.Update) string { return u.DriverClass }, nil},
+		{"driver-manufacturer", d.DriverManufacturer, func(u *updates.Update) string { return u.DriverManufacturer }, nil},
+		{"driver-model", d.DriverModel, func(u *updates.Update) string { return u.DriverModel }, nil},
+		{"driver-provider", d.DriverProvider, func(u *updates.Update) string { return u.DriverProvider }, nil},
+		{"driver-hardware-id", d.DriverHardwareID, func(u *updates.Update) string { return u.DriverHardwareID }, nil},
+		{"driver-date-version", d.DriverDateVer, nil, time.Time.Equal},
+		{"driver-date-before", d.DriverDateBefore, nil, time.Time.Before},
+		{"driver-date-after", d.DriverDateAfter, nil, time.Time.After},
+	}
+}
+
+// Validate returns an error if a pattern or date of the exclusion cannot be
+// used. An exclusion without criteria is valid and matches nothing.
+func (d DriverExclude) Validate() error {
+	for _, f := range d.fields() {
+		if f.value == "" {
+			continue
+		}
+		if f.get != nil {
+			if _, err := Pattern(f.value).regexp(); err != nil {
+				return fmt.Errorf("invalid %s pattern %q: %v", f.key, f.value, err)
+			}
+		} else if _, err := time.Parse(driverDate, f.value); err != nil {
+			return fmt.Errorf("invalid %s %q, want YYYY-MM-DD", f.key, f.value)
+		}
+	}
+	return nil
+}
+
+// Match returns true if u is a driver update matching every criteria of the
+// exclusion. A criteria never matches an update without that property, and
+// criteria that do not validate are ignored.
+func (d DriverExclude) Match(u *updates.Update) bool {
+	matched := false
+	for _, f := range d.fields() {
+		if f.value == "" {
+			continue
+		}
+		if f.get != nil {
+			if _, err := Pattern(f.value).regexp(); err != nil {
+				continue
+			}
+			if v := f.get(u); v == "" || !Pattern(f.value).Match(v) {
+				return false
+			}
+			matched = true
+			continue
+		}
+		t, err := time.Parse(driverDate, f.value)
+		if err != nil {
+			continue
+		}
+		if u.DriverVerDate.IsZero() || !f.date(u.DriverVerDate, t) {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/updates"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		p    Pattern
+		v    string
+		want bool
+	}{
+		{"Display", "Display", true},
+		{"display", "Display", true},
+		{"Display", "DisplayAdapter", false},
+		{"NVIDIA*", "NVIDIA Corporation", true},
+		{"*RTX ?080*", "NVIDIA GeForce RTX 4080", true},
+		{`PCI\VEN_10DE&DEV_*`, `PCI\VEN_10DE&DEV_2704`, true},
+		{`PCI\VEN_10DE&DEV_*`, `PCI\VEN_8086&DEV_2704`, false},
+		{"re:NVIDIA|AMD", "amd", true},
+		{"re:NVIDIA|AMD", "AMD Inc.", false},
+		{"re:(", "(", false},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Match(tt.v); got != tt.want {
+			t.Errorf("Pattern(%q).Match(%q) = %t, want %t", tt.p, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestDriverExcludeMatch(t *testing.T) {
+	gpu := &updates.Update{
+		Title:              "NVIDIA - Display - 31.0.15.5176",
+		DriverClass:        "Display",
+		DriverManufacturer: "NVIDIA",
+		DriverModel:        "NVIDIA RTX A2000",
+		DriverProvider:     "NVIDIA",
+		DriverHardwareID:   `PCI\VEN_10DE&DEV_2531`,
+		DriverVerDate:      time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	notDriver := &updates.Update{Title: "2026-03 Cumulative Update"}
+	tests := []struct {
+		desc string
+		d    DriverExclude
+		u    *updates.Update
+		want bool
+	}{
+		{"empty", DriverExclude{}, gpu, false},
+		{"class", DriverExclude{DriverClass: "Display"}, gpu, true},
+		{"vendor and model", DriverExclude{DriverManufacturer: "nvidia", DriverModel: "*A2000"}, gpu, true},
+		{"vendor other model", DriverExclude{DriverManufacturer: "nvidia", DriverModel: "*A4000"}, gpu, false},
+		{"hardware id", DriverExclude{DriverHardwareID: `re:PCI\\VEN_10DE&DEV_25..`}, gpu, true},
+		{"date version", DriverExclude{DriverDateVer: "2026-03-01"}, gpu, true},
+		{"date before", DriverExclude{DriverProvider: "NVIDIA", DriverDateBefore: "2026-03-02"}, gpu, true},
+		{"date not before", DriverExclude{DriverProvider: "NVIDIA", DriverDateBefore: "2026-03-01"}, gpu, false},
+		{"date range", DriverExclude{DriverDateAfter: "2026-01-01", DriverDateBefore: "2026-04-01"}, gpu, true},
+		{"date not after", DriverExclude{DriverDateAfter: "2026-03-01"}, gpu, false},
+		{"not a driver", DriverExclude{DriverDateBefore: "2100-01-01"}, notDriver, false},
+		{"pattern needs a value", DriverExclude{DriverClass: "*"}, notDriver, false},
+		{"invalid date ignored", DriverExclude{DriverClass: "Display", DriverDateVer: "yesterday"}, gpu, true},
+	}
+	for _, tt := range tests {
+		if got := tt.d.Match(tt.u); got != tt.want {
+			t.Errorf("Match(%s) = %t, want %t", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestDriverExcludeValidate(t *testing.T) {
+	tests := []struct {
+		d       DriverExclude
+		wantErr bool
+	}{
+		{DriverExclude{}, false},
+		{DriverExclude{DriverClass: "Display", DriverDateAfter: "2026-01-01"}, false},
+		{DriverExclude{DriverModel: "re:("}, true},
+		{DriverExclude{DriverDateVer: "yesterday"}, true},
+		{DriverExclude{DriverDateBefore: "12/31/2025"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.d.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%s) returned error %v, want error %t", tt.d, err, tt.wantErr)
+		}
+	}
+}
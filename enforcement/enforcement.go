@@ -12,9 +12,6 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-//go:build windows
-// +build windows
-
 // Package enforcement implements filesystem watching for configured required updates.
 package enforcement
 
@@ -24,11 +21,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/google/cabbie/cablib"
-
-	"gopkg.in/fsnotify.v1"
-	"github.com/google/glazier/go/helpers"
 )
 
 var (
@@ -45,22 +41,65 @@ type Enforcements struct {
 	ExcludedDrivers []DriverExclude `json:"excluded-drivers"`
 	Hidden          []string        `json:"hidden"`
 	HiddenUpdateID  []string        `json:"hidden-UpdateID"`
+	// Uninstall lists KBs or UpdateIDs to remove if installed, and keep hidden.
+	Uninstall []string `json:"uninstall"`
+	// RequiredCVEs, RequiredSeverity and RequiredCategories require updates
+	// by their metadata, and HiddenTitleRegex hides them by title. See Selector.
+	RequiredCVEs       []string `json:"required-cves"`
+	RequiredSeverity   []string `json:"required-severity"`
+	RequiredCategories []string `json:"required-categories"`
+	HiddenTitleRegex   []string `json:"hidden-title-regex"`
+
+	// Origins and Conflicts are filled in by Get.
+	Origins   map[string][]Origin `json:"-"`
+	Conflicts []Conflict          `json:"-"`
+	// InstallBy maps required updates to their install-by deadline, see Due.
+	InstallBy map[string]time.Time `json:"-"`
+	// OverrideBlackout lists the required updates installed, and the updates
+	// rolled back, even during a blackout period, see Emergency.
+	OverrideBlackout []string `json:"-"`
+	// NextChange is when a scheduled rule next becomes active, lapses or
+	// reaches its install-by deadline, if ever.
+	NextChange time.Time `json:"-"`
 }
 
 // DriverExclude specifies criteria to exclude certain driver updates.
 // A driver update is ignored by Cabbie if it matches all criteria.
+//
+// The class, manufacturer, model, provider and hardware ID are patterns, see
+// Pattern. Dates are written as YYYY-MM-DD.
 type DriverExclude struct {
-	DriverClass   string `json:"driver-class"`
+	DriverClass        string `json:"driver-class"`
+	DriverManufacturer string `json:"driver-manufacturer"`
+	DriverModel        string `json:"driver-model"`
+	DriverProvider     string `json:"driver-provider"`
+	DriverHardwareID   string `json:"driver-hardware-id"`
+	// DriverDateVer matches drivers of exactly this date.
 	DriverDateVer string `json:"driver-date-version"`
+	// DriverDateBefore and DriverDateAfter match drivers dated strictly
+	// before or after the date.
+	DriverDateBefore string `json:"driver-date-before"`
+	DriverDateAfter  string `json:"driver-date-after"`
+}
+
+func (d DriverExclude) String() string {
+	var c []string
+	for _, f := range d.fields() {
+		if f.value != "" {
+			c = append(c, fmt.Sprintf("%s=%q", f.key, f.value))
+		}
+	}
+	return strings.Join(c, " ")
 }
 
+// enforcements returns the rules of the file at path that are active now.
 func enforcements(path string) (Enforcements, error) {
 	var e Enforcements
 	path = filepath.Clean(path)
 	if filepath.Ext(path) != ".json" {
 		return e, fmt.Errorf("%w: %q", errFileType, path)
 	}
-	b, err := helpers.PathExists(path)
+	b, err := cablib.PathExists(path)
 	if err != nil {
 		return e, fmt.Errorf("error determining %q existence: %v", path, err)
 	}
@@ -71,33 +110,94 @@ func enforcements(path string) (Enforcements, error) {
 	if err != nil {
 		return e, fmt.Errorf("error reading file %q: %v", path, err)
 	}
-	if err := json.Unmarshal(data, &e); err != nil {
+	sig, err := readSignature(path)
+	if err != nil {
+		return e, fmt.Errorf("error reading signature of %q: %v", path, err)
+	}
+	if err := currentTrust().verify(data, sig); err != nil {
+		return e, fmt.Errorf("%w for %q", err, path)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
 		return e, fmt.Errorf("%w for %q: %v", errParsing, path, err)
 	}
-	return e, nil
+	if err := f.check(); err != nil {
+		return e, fmt.Errorf("%w for %q: %v", errParsing, path, err)
+	}
+	return f.active(now()), nil
+}
+
+// FileError reports an enforcement file that was skipped because it could
+// not be read or parsed.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
 }
 
-// Get attempts to return all known external enforcements.
-func Get() (Enforcements, error) {
+// Get attempts to return all known external enforcements, from the files in
+// the enforcement directory and the cached remote document, if any. Files that
+// cannot be used are skipped and reported individually, so that one broken
+// file does not disable the others. Required updates that another file hides
+// or uninstalls are dropped, see Conflict.
+func Get() (Enforcements, []FileError, error) {
 	var ret Enforcements
+	var fileErrs []FileError
 	files, err := os.ReadDir(enforceDir)
 	if err != nil {
-		return ret, err
+		return ret, nil, err
 	}
+	var paths []string
 	for _, f := range files {
-		p := filepath.Join(enforceDir, f.Name())
+		// Signatures are read along with the file they sign.
+		if !f.IsDir() && filepath.Ext(f.Name()) != SigExt {
+			paths = append(paths, filepath.Join(enforceDir, f.Name()))
+		}
+	}
+	if _, err := os.Stat(remoteCache()); err == nil {
+		paths = append(paths, remoteCache())
+	}
+	for _, p := range paths {
 		e, err := enforcements(p)
 		if err != nil {
-			// TODO(mattl): surface errors here somehow
+			fileErrs = append(fileErrs, FileError{Path: p, Err: err})
 			continue
 		}
+		o := Origin{Path: p}
+		if fi, err := os.Stat(p); err == nil {
+			o.ModTime = fi.ModTime()
+		}
+		ret.addOrigins(e, o)
+		ret.mergeInstallBy(e)
 		ret.Required = append(ret.Required, e.Required...)
 		ret.Hidden = append(ret.Hidden, e.Hidden...)
 		ret.ExcludedDrivers = append(ret.ExcludedDrivers, e.ExcludedDrivers...)
 		ret.HiddenUpdateID = append(ret.HiddenUpdateID, e.HiddenUpdateID...)
+		ret.Uninstall = append(ret.Uninstall, e.Uninstall...)
+		ret.RequiredCVEs = append(ret.RequiredCVEs, e.RequiredCVEs...)
+		ret.RequiredSeverity = append(ret.RequiredSeverity, e.RequiredSeverity...)
+		ret.RequiredCategories = append(ret.RequiredCategories, e.RequiredCategories...)
+		ret.HiddenTitleRegex = append(ret.HiddenTitleRegex, e.HiddenTitleRegex...)
+		ret.OverrideBlackout = append(ret.OverrideBlackout, e.OverrideBlackout...)
+		if !e.NextChange.IsZero() && (ret.NextChange.IsZero() || e.NextChange.Before(ret.NextChange)) {
+			ret.NextChange = e.NextChange
+		}
+	}
+	for id, d := range ret.InstallBy {
+		if d.IsZero() {
+			delete(ret.InstallBy, id)
+		}
 	}
 	ret.dedupe()
-	return ret, nil
+	ret.resolve()
+	return ret, fileErrs, nil
 }
 
 // go generics are super new. The following two funcs should be merged
@@ -131,37 +231,11 @@ func (e *Enforcements) dedupe() {
 	e.Required = uniqueStrings(e.Required)
 	e.Hidden = uniqueStrings(e.Hidden)
 	e.HiddenUpdateID = uniqueStrings(e.HiddenUpdateID)
+	e.Uninstall = uniqueStrings(e.Uninstall)
+	e.RequiredCVEs = uniqueStrings(e.RequiredCVEs)
+	e.RequiredSeverity = uniqueStrings(e.RequiredSeverity)
+	e.RequiredCategories = uniqueStrings(e.RequiredCategories)
+	e.HiddenTitleRegex = uniqueStrings(e.HiddenTitleRegex)
+	e.OverrideBlackout = uniqueStrings(e.OverrideBlackout)
 	e.ExcludedDrivers = uniqueDriverExclude(e.ExcludedDrivers)
 }
-
-// Watcher runs a filesystem watcher for required updates. This is meant to install required updates as soon as they are configured.
-// All configured required updates are read on a schedule (see cabbie.go t.Enforcement ticker usage) to ensure required
-// updates are installed even if a filesystem event is missed.
-func Watcher(file chan<- string) error {
-	fsw, err := fsnotify.NewWatcher()
-	if err != nil {
-		return fmt.Errorf("enforce: error creating filesystem watcher:\n%v", err)
-	}
-	defer fsw.Close()
-
-	exist, err := helpers.PathExists(enforceDir)
-	if err != nil {
-		return fmt.Errorf("enforce: error checking existence of %q:\n%v", enforceDir, err)
-	}
-	if !exist {
-		if err := os.MkdirAll(enforceDir, 0664); err != nil {
-			return fmt.Errorf("enforce: error creating %q:\n%v", enforceDir, err)
-		}
-	}
-
-	if err := fsw.Add(enforceDir); err != nil {
-		return fmt.Errorf("enforce: error adding %q to filesystem watcher:\n%v", enforceDir, err)
-	}
-
-	for {
-		evt := <-fsw.Events
-		if cablib.SliceContains([]fsnotify.Op{fsnotify.Write, fsnotify.Create}, evt.Op) {
-			file <- evt.Name
-		}
-	}
-}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -53,6 +54,11 @@ func TestDedupe(t *testing.T) {
 			Enforcements{Hidden: []string{"4018073", "67891011", "4018073", "4018073"}},
 			Enforcements{Hidden: []string{"4018073", "67891011"}},
 		},
+		{
+			"with dup uninstall",
+			Enforcements{Uninstall: []string{"4018073", "1234ccd5-1234-456f-78gh-ij2911553881", "4018073"}},
+			Enforcements{Uninstall: []string{"4018073", "1234ccd5-1234-456f-78gh-ij2911553881"}},
+		},
 		{
 			"with dup excluded drivers",
 			Enforcements{ExcludedDrivers: []DriverExclude{
@@ -107,10 +113,30 @@ func TestEnforcements(t *testing.T) {
 			}},
 			nil,
 		},
+		{"uninstall.json",
+			Enforcements{Uninstall: []string{"4018073", "1234ccd5-1234-456f-78gh-ij2911553881"}},
+			nil,
+		},
+		{"selectors.json",
+			Enforcements{
+				RequiredCVEs:     []string{"CVE-2026-21001"},
+				RequiredSeverity: []string{"Critical"},
+				HiddenTitleRegex: []string{"(?i)preview"},
+			},
+			nil,
+		},
 		{"invalid.json",
 			Enforcements{},
 			errParsing,
 		},
+		{"bad-regex.json",
+			Enforcements{},
+			errParsing,
+		},
+		{"bad-driver-date.json",
+			Enforcements{},
+			errParsing,
+		},
 		{"missing.json",
 			Enforcements{},
 			errInvalidFile,
@@ -134,3 +160,82 @@ func TestEnforcements(t *testing.T) {
 		})
 	}
 }
+
+func TestGet(t *testing.T) {
+	defer func(d string) { enforceDir = d }(enforceDir)
+	enforceDir = testData
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	got, fileErrs, err := Get()
+	if err != nil {
+		t.Fatalf("Get() returned unexpected error: %v", err)
+	}
+	// Every other required update in testdata is also hidden or uninstalled.
+	if diff := cmp.Diff([]string{"5031356", "5000001", "5000002"}, got.Required); diff != "" {
+		t.Errorf("Get() returned unexpected required updates (-want +got):\n%s", diff)
+	}
+	wantConflicts := []Conflict{
+		{ID: "4018073", Keys: []string{KeyUninstall, KeyHidden, KeyRequired}, Winner: KeyUninstall},
+		{ID: "67891011", Keys: []string{KeyHidden, KeyRequired}, Winner: KeyHidden},
+	}
+	if diff := cmp.Diff(wantConflicts, got.Conflicts, cmpopts.IgnoreFields(Conflict{}, "Origins")); diff != "" {
+		t.Errorf("Get() returned unexpected conflicts (-want +got):\n%s", diff)
+	}
+	// 5000001 is also required without a deadline, in scheduled.json.
+	wantInstallBy := map[string]time.Time{"5031356": time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)}
+	if diff := cmp.Diff(wantInstallBy, got.InstallBy); diff != "" {
+		t.Errorf("Get() returned unexpected install-by deadlines (-want +got):\n%s", diff)
+	}
+	if want := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC); !got.NextChange.Equal(want) {
+		t.Errorf("Get() returned next change %s, want %s", got.NextChange, want)
+	}
+	var origins []string
+	for _, o := range got.OriginsOf(KeyRequired, "4018073") {
+		origins = append(origins, filepath.Base(o.Path))
+	}
+	if diff := cmp.Diff([]string{"required.json", "unknown-key.json"}, origins); diff != "" {
+		t.Errorf("OriginsOf(required, 4018073) returned unexpected diff (-want +got):\n%s", diff)
+	}
+	if len(got.Conflicts) > 0 {
+		if o := got.Conflicts[0].Origins[KeyUninstall]; len(o) != 1 || filepath.Base(o[0].Path) != "uninstall.json" {
+			t.Errorf("Get() returned conflict origins %v, want uninstall.json", o)
+		}
+	}
+	wantErrs := map[string]error{
+		"bad-regex.json":             errParsing,
+		"bad-driver-date.json":       errParsing,
+		"bad-install-by.json":        errParsing,
+		"bad-override-blackout.json": errParsing,
+		"invalid.json":               errParsing,
+		"wrong-type.json":            errParsing,
+	}
+	if len(fileErrs) != len(wantErrs) {
+		t.Errorf("Get() returned file errors %v, want %d", fileErrs, len(wantErrs))
+	}
+	for _, fe := range fileErrs {
+		if want := wantErrs[filepath.Base(fe.Path)]; !errors.Is(fe, want) {
+			t.Errorf("Get() returned file error %v, want %v", fe, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	e := Enforcements{
+		Required:       []string{"KB1", "2", "3", "4"},
+		Hidden:         []string{"1"},
+		HiddenUpdateID: []string{"abc"},
+		Uninstall:      []string{"kb2"},
+	}
+	e.resolve()
+	if diff := cmp.Diff([]string{"3", "4"}, e.Required); diff != "" {
+		t.Errorf("resolve() returned unexpected required updates (-want +got):\n%s", diff)
+	}
+	want := []Conflict{
+		{ID: "KB1", Keys: []string{KeyHidden, KeyRequired}, Winner: KeyHidden},
+		{ID: "2", Keys: []string{KeyUninstall, KeyRequired}, Winner: KeyUninstall},
+	}
+	if diff := cmp.Diff(want, e.Conflicts, cmpopts.IgnoreFields(Conflict{}, "Origins")); diff != "" {
+		t.Errorf("resolve() returned unexpected conflicts (-want +got):\n%s", diff)
+	}
+}
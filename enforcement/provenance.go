@@ -0,0 +1,146 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Keys of the rule lists in an enforcement file.
+const (
+	KeyRequired        = "required"
+	KeyHidden          = "hidden"
+	KeyHiddenUpdateID  = "hidden-UpdateID"
+	KeyUninstall       = "uninstall"
+	KeyExcludedDrivers = "excluded-drivers"
+
+	KeyRequiredCVEs       = "required-cves"
+	KeyRequiredSeverity   = "required-severity"
+	KeyRequiredCategories = "required-categories"
+	KeyHiddenTitleRegex   = "hidden-title-regex"
+)
+
+// Origin is the enforcement file a rule was read from.
+type Origin struct {
+	Path    string
+	ModTime time.Time
+}
+
+func (o Origin) String() string {
+	return fmt.Sprintf("%s (modified %s)", o.Path, o.ModTime.Format(time.RFC3339))
+}
+
+// Conflict is an update named under keys with opposing effects. Conflicts are
+// resolved by precedence: uninstall wins over hidden and hidden wins over
+// required, so an update is never installed while any file asks to block it.
+type Conflict struct {
+	// ID is the KB article ID or UpdateID.
+	ID string
+	// Keys are the keys naming the update, highest precedence first.
+	Keys []string
+	// Winner is the key that is applied.
+	Winner string
+	// Origins are the files that name the update, by key.
+	Origins map[string][]Origin
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s is listed under %s; %s wins", c.ID, strings.Join(c.Keys, " and "), c.Winner)
+}
+
+// originKey identifies a rule within the merged enforcements.
+func originKey(key, value string) string {
+	return key + "\x00" + value
+}
+
+// addOrigins records o as the origin of every rule in e.
+func (e *Enforcements) addOrigins(from Enforcements, o Origin) {
+	if e.Origins == nil {
+		e.Origins = make(map[string][]Origin)
+	}
+	add := func(key string, values []string) {
+		for _, v := range values {
+			k := originKey(key, v)
+			e.Origins[k] = append(e.Origins[k], o)
+		}
+	}
+	add(KeyRequired, from.Required)
+	add(KeyHidden, from.Hidden)
+	add(KeyHiddenUpdateID, from.HiddenUpdateID)
+	add(KeyUninstall, from.Uninstall)
+	add(KeyRequiredCVEs, from.RequiredCVEs)
+	add(KeyRequiredSeverity, from.RequiredSeverity)
+	add(KeyRequiredCategories, from.RequiredCategories)
+	add(KeyHiddenTitleRegex, from.HiddenTitleRegex)
+	for _, d := range from.ExcludedDrivers {
+		add(KeyExcludedDrivers, []string{d.String()})
+	}
+}
+
+// OriginsOf returns the files that set value under key. Driver exclusions are
+// identified by their String form.
+func (e Enforcements) OriginsOf(key, value string) []Origin {
+	return e.Origins[originKey(key, value)]
+}
+
+// normalizeKB strips the KB prefix so that "KB123" and "123" compare equal.
+func normalizeKB(s string) string {
+	return strings.TrimPrefix(strings.ToUpper(s), "KB")
+}
+
+// resolve removes required updates that are also hidden or uninstalled, and
+// records each such conflict.
+func (e *Enforcements) resolve() {
+	e.Conflicts = nil
+	// Map the normalized IDs to the values as written, for their origins.
+	index := func(values []string) map[string]string {
+		m := make(map[string]string)
+		for _, v := range values {
+			m[normalizeKB(v)] = v
+		}
+		return m
+	}
+	blocking := []struct {
+		key    string
+		values map[string]string
+	}{
+		{KeyUninstall, index(e.Uninstall)},
+		{KeyHidden, index(e.Hidden)},
+		{KeyHiddenUpdateID, index(e.HiddenUpdateID)},
+	}
+
+	required := make([]string, 0, len(e.Required))
+	for _, v := range e.Required {
+		n := normalizeKB(v)
+		c := Conflict{ID: v, Origins: make(map[string][]Origin)}
+		for _, b := range blocking {
+			if bv, ok := b.values[n]; ok {
+				c.Keys = append(c.Keys, b.key)
+				c.Origins[b.key] = e.OriginsOf(b.key, bv)
+			}
+		}
+		if len(c.Keys) == 0 {
+			required = append(required, v)
+			continue
+		}
+		c.Winner = c.Keys[0]
+		c.Keys = append(c.Keys, KeyRequired)
+		c.Origins[KeyRequired] = e.OriginsOf(KeyRequired, v)
+		e.Conflicts = append(e.Conflicts, c)
+	}
+	e.Required = required
+}
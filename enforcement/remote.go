@@ -0,0 +1,200 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// maxRemoteSize limits the size of a remote enforcement document.
+const maxRemoteSize = 1 << 20
+
+var errRemote = errors.New("remote enforcement fetch failed")
+
+// remoteDir holds the cached remote document. Get reads the enforcement
+// directory itself without descending into it.
+func remoteDir() string {
+	return filepath.Join(enforceDir, "remote")
+}
+
+// remoteCache is the last good copy of the remote enforcement document.
+func remoteCache() string {
+	return filepath.Join(remoteDir(), "enforcement.json")
+}
+
+// remoteState records the validators of the cached document, so that an
+// unchanged document is not downloaded again.
+type remoteState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last-modified"`
+}
+
+func remoteStatePath() string {
+	return filepath.Join(remoteDir(), "state.json")
+}
+
+// Remote fetches an enforcement document from an HTTPS URL. The last good
+// copy is cached on disk and merged with the local files by Get, so the
+// enforcements it sets stay in effect while the server cannot be reached.
+type Remote struct {
+	URL string
+	// Client is used for requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Fetch downloads the document if it changed since the last fetch, and
+// returns true if the cached copy was replaced. A document that cannot be
+// parsed is rejected, keeping the previous copy.
+func (r *Remote) Fetch(ctx context.Context) (bool, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return false, fmt.Errorf("%w: invalid URL %q: %v", errRemote, r.URL, err)
+	}
+	if u.Scheme != "https" {
+		return false, fmt.Errorf("%w: %q is not an https URL", errRemote, r.URL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errRemote, err)
+	}
+	var st remoteState
+	if _, err := os.Stat(remoteCache()); err == nil {
+		if b, err := os.ReadFile(remoteStatePath()); err == nil && json.Unmarshal(b, &st) == nil && st.URL == r.URL {
+			if st.ETag != "" {
+				req.Header.Set("If-None-Match", st.ETag)
+			}
+			if st.LastModified != "" {
+				req.Header.Set("If-Modified-Since", st.LastModified)
+			}
+		}
+	}
+
+	c := r.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errRemote, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("%w: %s returned %s", errRemote, r.URL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize+1))
+	if err != nil {
+		return false, fmt.Errorf("%w: error reading %s: %v", errRemote, r.URL, err)
+	}
+	if len(data) > maxRemoteSize {
+		return false, fmt.Errorf("%w: %s is larger than %d bytes", errRemote, r.URL, maxRemoteSize)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return false, fmt.Errorf("%w for %s: %v", errParsing, r.URL, err)
+	}
+	if err := f.check(); err != nil {
+		return false, fmt.Errorf("%w for %s: %v", errParsing, r.URL, err)
+	}
+	sig, err := r.signature(ctx, c, u)
+	if err != nil {
+		return false, err
+	}
+	if err := currentTrust().verify(data, sig); err != nil {
+		return false, fmt.Errorf("%w for %s", err, r.URL)
+	}
+
+	if err := os.MkdirAll(remoteDir(), 0755); err != nil {
+		return false, fmt.Errorf("error creating %q: %v", remoteDir(), err)
+	}
+	old, _ := os.ReadFile(remoteCache())
+	oldSig, _ := readSignature(remoteCache())
+	changed := !bytes.Equal(old, data) || !bytes.Equal(oldSig, sig)
+	if changed {
+		// Replace the signature first, so that a failure in between leaves a
+		// copy that does not verify rather than one that verifies wrongly.
+		if sig == nil {
+			if err := os.Remove(remoteCache() + SigExt); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return false, err
+			}
+		} else if err := writeFile(remoteCache()+SigExt, sig); err != nil {
+			return false, err
+		}
+		if err := writeFile(remoteCache(), data); err != nil {
+			return false, err
+		}
+	}
+	st = remoteState{URL: r.URL, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return changed, err
+	}
+	return changed, writeFile(remoteStatePath(), b)
+}
+
+// signature downloads the detached signature of the document at u, published
+// at its path with SigExt appended. It returns nil if there is none.
+func (r *Remote) signature(ctx context.Context, c *http.Client, u *url.URL) ([]byte, error) {
+	s := *u
+	s.Path += SigExt
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errRemote, err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errRemote, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("%w: %s returned %s", errRemote, s.String(), resp.Status)
+	}
+	sig, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return nil, fmt.Errorf("%w: error reading %s: %v", errRemote, s.String(), err)
+	}
+	return sig, nil
+}
+
+// writeFile replaces the file at path with data, so that readers never see a
+// partial write.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing %q: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error replacing %q: %v", path, err)
+	}
+	return nil
+}
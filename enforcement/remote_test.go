@@ -0,0 +1,103 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRemoteFetch(t *testing.T) {
+	defer func(d string) { enforceDir = d }(enforceDir)
+	enforceDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(enforceDir, "local.json"), []byte(`{"required": ["1111111"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"required": ["2222222"], "hidden": ["3333333"]}`
+	status := http.StatusOK
+	var gotIfNoneMatch []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/enforcement.json" {
+			http.NotFound(w, r)
+			return
+		}
+		gotIfNoneMatch = append(gotIfNoneMatch, r.Header.Get("If-None-Match"))
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		etag := fmt.Sprintf("%q", fmt.Sprint(len(body)))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+	r := &Remote{URL: ts.URL + "/enforcement.json", Client: ts.Client()}
+	ctx := context.Background()
+
+	for _, step := range []struct {
+		desc        string
+		body        string
+		status      int
+		wantChanged bool
+		wantErr     error
+	}{
+		{"first fetch", body, http.StatusOK, true, nil},
+		{"not modified", body, http.StatusOK, false, nil},
+		{"invalid document", `{"required": [`, http.StatusOK, false, errParsing},
+		{"server error", body, http.StatusInternalServerError, false, errRemote},
+	} {
+		body, status = step.body, step.status
+		changed, err := r.Fetch(ctx)
+		if changed != step.wantChanged || !errors.Is(err, step.wantErr) {
+			t.Errorf("Fetch(%s) = %t, %v, want %t, %v", step.desc, changed, err, step.wantChanged, step.wantErr)
+		}
+	}
+	etag := fmt.Sprintf("%q", fmt.Sprint(len(body)))
+	if diff := cmp.Diff([]string{"", etag, etag, etag}, gotIfNoneMatch); diff != "" {
+		t.Errorf("Fetch() sent unexpected If-None-Match headers (-want +got):\n%s", diff)
+	}
+
+	// The last good copy is merged with the local files.
+	got, fileErrs, err := Get()
+	if err != nil || len(fileErrs) > 0 {
+		t.Fatalf("Get() returned unexpected errors: %v %v", fileErrs, err)
+	}
+	if diff := cmp.Diff([]string{"1111111", "2222222"}, got.Required); diff != "" {
+		t.Errorf("Get() returned unexpected required updates (-want +got):\n%s", diff)
+	}
+	if o := got.OriginsOf(KeyHidden, "3333333"); len(o) != 1 || o[0].Path != remoteCache() {
+		t.Errorf("OriginsOf(hidden, 3333333) = %v, want %s", o, remoteCache())
+	}
+}
+
+func TestRemoteFetchHTTP(t *testing.T) {
+	r := &Remote{URL: "http://example.com/enforcement.json"}
+	if _, err := r.Fetch(context.Background()); !errors.Is(err, errRemote) {
+		t.Errorf("Fetch(%s) returned error %v, want %v", r.URL, err, errRemote)
+	}
+}
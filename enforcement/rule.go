@@ -0,0 +1,285 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// now is the time rules are evaluated at. Tests replace it.
+var now = time.Now
+
+// Schedule limits when a rule applies. Unset times do not limit it.
+type Schedule struct {
+	NotBefore Time `json:"not-before"`
+	Expires   Time `json:"expires"`
+}
+
+// Active returns true if the rule applies at t.
+func (s Schedule) Active(t time.Time) bool {
+	if !s.NotBefore.IsZero() && t.Before(s.NotBefore.Time) {
+		return false
+	}
+	return s.Expires.IsZero() || t.Before(s.Expires.Time)
+}
+
+// next returns the first time after t when the rule becomes active or lapses,
+// or the zero time if it never changes again.
+func (s Schedule) next(t time.Time) time.Time {
+	for _, c := range []time.Time{s.NotBefore.Time, s.Expires.Time} {
+		if !c.IsZero() && c.After(t) {
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+// Time is a timestamp in an enforcement file, written in RFC 3339 form or as
+// a date, which is midnight local time.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	if v, err := time.Parse(time.RFC3339, s); err == nil {
+		t.Time = v
+		return nil
+	}
+	v, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid time %q, want RFC 3339 or YYYY-MM-DD", s)
+	}
+	t.Time = v
+	return nil
+}
+
+// Rule is an entry of the required, hidden, hidden-UpdateID, uninstall and
+// selector lists. It is written either as a plain string, such as a KB,
+// UpdateID or CVE ID, or as an object with an id (or kb) and an optional
+// schedule.
+type Rule struct {
+	ID string `json:"id"`
+	// KB is accepted in place of ID, and moved to ID when decoded.
+	KB string `json:"kb"`
+	// InstallBy is the deadline of a required update. Until then, the update
+	// is only installed in the maintenance window.
+	InstallBy Time `json:"install-by"`
+	// OverrideBlackout installs a required update, or rolls back an update to
+	// uninstall, and restarts the device for it, even during a blackout period.
+	OverrideBlackout bool `json:"override-blackout"`
+	Schedule
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Rule) UnmarshalJSON(b []byte) error {
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '"' {
+		*r = Rule{}
+		return json.Unmarshal(b, &r.ID)
+	}
+	// The alias drops this method so the object form decodes normally.
+	type rule Rule
+	var v rule
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch {
+	case v.ID == "":
+		v.ID, v.KB = v.KB, ""
+	case v.KB != "":
+		return fmt.Errorf("rule %s has both an id and a kb", b)
+	}
+	if v.ID == "" {
+		return fmt.Errorf("rule %s has no id", b)
+	}
+	*r = Rule(v)
+	return nil
+}
+
+// driverRule is an entry of the excluded-drivers list.
+type driverRule struct {
+	DriverExclude
+	Schedule
+}
+
+// file is the form of an enforcement file on disk.
+type file struct {
+	Required        []Rule       `json:"required"`
+	ExcludedDrivers []driverRule `json:"excluded-drivers"`
+	Hidden          []Rule       `json:"hidden"`
+	HiddenUpdateID  []Rule       `json:"hidden-UpdateID"`
+	Uninstall       []Rule       `json:"uninstall"`
+
+	RequiredCVEs       []Rule `json:"required-cves"`
+	RequiredSeverity   []Rule `json:"required-severity"`
+	RequiredCategories []Rule `json:"required-categories"`
+	HiddenTitleRegex   []Rule `json:"hidden-title-regex"`
+}
+
+// check returns an error for values that decode but cannot be used, whether
+// or not their rule is active.
+func (f file) check() error {
+	var s Selector
+	for _, r := range f.HiddenTitleRegex {
+		s.TitleRegex = append(s.TitleRegex, r.ID)
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	for _, d := range f.ExcludedDrivers {
+		if err := d.DriverExclude.Validate(); err != nil {
+			return fmt.Errorf("excluded-drivers: %v", err)
+		}
+	}
+	// install-by only applies to required updates, and override-blackout to
+	// required updates and rollbacks.
+	for _, l := range []struct {
+		key      string
+		rules    []Rule
+		override bool
+	}{
+		{KeyHidden, f.Hidden, false},
+		{KeyHiddenUpdateID, f.HiddenUpdateID, false},
+		{KeyUninstall, f.Uninstall, true},
+		{KeyRequiredCVEs, f.RequiredCVEs, false},
+		{KeyRequiredSeverity, f.RequiredSeverity, false},
+		{KeyRequiredCategories, f.RequiredCategories, false},
+		{KeyHiddenTitleRegex, f.HiddenTitleRegex, false},
+	} {
+		for _, r := range l.rules {
+			switch {
+			case !r.InstallBy.IsZero():
+				return fmt.Errorf("%s: %s sets install-by, which only applies to required updates", l.key, r.ID)
+			case r.OverrideBlackout && !l.override:
+				return fmt.Errorf("%s: %s sets override-blackout, which only applies to required and uninstall entries", l.key, r.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// active returns the rules of the file that apply at t, and the first time
+// after t when that set changes.
+func (f file) active(t time.Time) Enforcements {
+	var e Enforcements
+	later := func(n time.Time) {
+		if n.After(t) && (e.NextChange.IsZero() || n.Before(e.NextChange)) {
+			e.NextChange = n
+		}
+	}
+	ids := func(rules []Rule) []string {
+		var out []string
+		for _, r := range rules {
+			later(r.next(t))
+			if r.Active(t) {
+				out = append(out, r.ID)
+			}
+		}
+		return out
+	}
+	e.Required = ids(f.Required)
+	e.Hidden = ids(f.Hidden)
+	e.HiddenUpdateID = ids(f.HiddenUpdateID)
+	e.Uninstall = ids(f.Uninstall)
+	e.RequiredCVEs = ids(f.RequiredCVEs)
+	e.RequiredSeverity = ids(f.RequiredSeverity)
+	e.RequiredCategories = ids(f.RequiredCategories)
+	e.HiddenTitleRegex = ids(f.HiddenTitleRegex)
+	for _, r := range f.Required {
+		if r.InstallBy.IsZero() || !r.Active(t) {
+			continue
+		}
+		if e.InstallBy == nil {
+			e.InstallBy = make(map[string]time.Time)
+		}
+		e.InstallBy[r.ID] = r.InstallBy.Time
+		later(r.InstallBy.Time)
+	}
+	for _, rules := range [][]Rule{f.Required, f.Uninstall} {
+		for _, r := range rules {
+			if r.OverrideBlackout && r.Active(t) {
+				e.OverrideBlackout = append(e.OverrideBlackout, r.ID)
+			}
+		}
+	}
+	for _, d := range f.ExcludedDrivers {
+		later(d.next(t))
+		if d.Active(t) {
+			e.ExcludedDrivers = append(e.ExcludedDrivers, d.DriverExclude)
+		}
+	}
+	return e
+}
+
+// mergeInstallBy adds the install-by deadlines of the required updates of
+// from. An update keeps its earliest deadline, and none if any file requires
+// it without one. Updates required without a deadline are recorded with the
+// zero time until Get removes them.
+func (e *Enforcements) mergeInstallBy(from Enforcements) {
+	if e.InstallBy == nil {
+		e.InstallBy = make(map[string]time.Time)
+	}
+	for _, id := range from.Required {
+		d := from.InstallBy[id]
+		if cur, ok := e.InstallBy[id]; ok && (cur.IsZero() || (!d.IsZero() && cur.Before(d))) {
+			continue
+		}
+		e.InstallBy[id] = d
+	}
+}
+
+// Due splits the required updates by what to do with them at t: install them
+// now, install them now because their install-by deadline has passed, or
+// defer them to the maintenance window.
+func (e Enforcements) Due(t time.Time) (now, overdue, deferred []string) {
+	for _, id := range e.Required {
+		d, ok := e.InstallBy[id]
+		switch {
+		case !ok || d.IsZero():
+			now = append(now, id)
+		case t.Before(d):
+			deferred = append(deferred, id)
+		default:
+			overdue = append(overdue, id)
+		}
+	}
+	return now, overdue, deferred
+}
+
+// Emergency returns the ids that are required or uninstalled with
+// override-blackout.
+func (e Enforcements) Emergency(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		for _, o := range e.OverrideBlackout {
+			if id == o {
+				out = append(out, id)
+				break
+			}
+		}
+	}
+	return out
+}
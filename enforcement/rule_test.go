@@ -0,0 +1,181 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRuleUnmarshal(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		in      string
+		want    Rule
+		wantErr bool
+	}{
+		{`"4018073"`, Rule{ID: "4018073"}, false},
+		{`{"id": "4018073"}`, Rule{ID: "4018073"}, false},
+		{`{"id": "4018073", "not-before": "2026-05-01", "expires": "2026-05-15T12:00:00Z"}`,
+			Rule{ID: "4018073", Schedule: Schedule{
+				NotBefore: Time{day},
+				Expires:   Time{time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)},
+			}},
+			false,
+		},
+		{`{"kb": "5031356", "install-by": "2026-11-01T00:00:00Z"}`,
+			Rule{ID: "5031356", InstallBy: Time{time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)}},
+			false,
+		},
+		{`{"id": "5031356", "kb": "5031356"}`, Rule{}, true},
+		{`{"not-before": "2026-05-01"}`, Rule{}, true},
+		{`{"id": "4018073", "expires": "next week"}`, Rule{}, true},
+		{`12345`, Rule{}, true},
+	}
+	for _, tt := range tests {
+		var got Rule
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("json.Unmarshal(%s) returned error %v, want error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("json.Unmarshal(%s) returned unexpected diff (-want +got):\n%s", tt.in, diff)
+		}
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 14)
+	s := Schedule{NotBefore: Time{start}, Expires: Time{end}}
+	tests := []struct {
+		desc     string
+		at       time.Time
+		want     bool
+		wantNext time.Time
+	}{
+		{"before", start.Add(-time.Second), false, start},
+		{"at start", start, true, end},
+		{"during", start.AddDate(0, 0, 7), true, end},
+		{"at expiry", end, false, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := s.Active(tt.at); got != tt.want {
+			t.Errorf("Active(%s) = %t, want %t", tt.desc, got, tt.want)
+		}
+		if got := s.next(tt.at); !got.Equal(tt.wantNext) {
+			t.Errorf("next(%s) = %s, want %s", tt.desc, got, tt.wantNext)
+		}
+	}
+	if !(Schedule{}).Active(start) {
+		t.Errorf("Active() of an unscheduled rule = false, want true")
+	}
+}
+
+func TestScheduledEnforcements(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	path := filepath.Join(testData, "scheduled.json")
+	tests := []struct {
+		at   time.Time
+		want Enforcements
+	}{
+		{
+			time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+			Enforcements{
+				Required:        []string{"5000001", "5000003"},
+				ExcludedDrivers: []DriverExclude{{DriverClass: "Printer"}},
+				NextChange:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+			},
+		},
+		{
+			time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC),
+			Enforcements{
+				Required:   []string{"5000001", "5000002", "5000003"},
+				NextChange: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+			Enforcements{
+				Required:   []string{"5000001", "5000002"},
+				NextChange: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		now = func() time.Time { return tt.at }
+		got, err := enforcements(path)
+		if err != nil {
+			t.Fatalf("enforcements(%s) returned unexpected error: %v", path, err)
+		}
+		if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("enforcements(%s) at %s returned unexpected diff (-want +got):\n%s", path, tt.at, diff)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	deadline := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	e := Enforcements{
+		Required:  []string{"1", "2", "3"},
+		InstallBy: map[string]time.Time{"2": deadline, "3": deadline.AddDate(0, 1, 0)},
+	}
+	now, overdue, deferred := e.Due(deadline)
+	got := [][]string{now, overdue, deferred}
+	want := [][]string{{"1"}, {"2"}, {"3"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Due(%s) returned unexpected diff (-want +got):\n%s", deadline, diff)
+	}
+}
+
+func TestMergeInstallBy(t *testing.T) {
+	early := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	late := early.AddDate(0, 0, 7)
+	var e Enforcements
+	for _, from := range []Enforcements{
+		{Required: []string{"1", "2", "3"}, InstallBy: map[string]time.Time{"1": late, "2": early}},
+		{Required: []string{"1", "2", "3"}, InstallBy: map[string]time.Time{"1": early, "3": early}},
+	} {
+		e.mergeInstallBy(from)
+	}
+	want := map[string]time.Time{"1": early, "2": time.Time{}, "3": time.Time{}}
+	if diff := cmp.Diff(want, e.InstallBy); diff != "" {
+		t.Errorf("mergeInstallBy() returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestEmergency(t *testing.T) {
+	in := `{"required": [{"kb": "5031356", "override-blackout": true}, "5031357", {"id": "5031358", "override-blackout": true, "expires": "2020-01-01"}], "uninstall": [{"kb": "5031359", "override-blackout": true}]}`
+	var f file
+	if err := json.Unmarshal([]byte(in), &f); err != nil {
+		t.Fatal(err)
+	}
+	e := f.active(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+	if diff := cmp.Diff([]string{"5031356", "5031359"}, e.OverrideBlackout); diff != "" {
+		t.Errorf("active() OverrideBlackout returned unexpected diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"5031356", "5031359"}, e.Emergency([]string{"5031357", "5031356", "5031359"})); diff != "" {
+		t.Errorf("Emergency() returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
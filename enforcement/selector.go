@@ -0,0 +1,135 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/cabbie/updates"
+)
+
+// Selector matches updates by their metadata rather than by KB or UpdateID.
+// An update matches if it matches any of the listed values.
+type Selector struct {
+	// CVEs match updates fixing any of the CVE IDs.
+	CVEs []string
+	// Severities match the MSRC severity of updates, e.g. Critical.
+	Severities []string
+	// Categories match updates in any of the category names.
+	Categories []string
+	// TitleRegex match updates whose title matches any of the expressions.
+	TitleRegex []string
+
+	// titleRe are the compiled TitleRegex, see Compile.
+	titleRe  []*regexp.Regexp
+	compiled bool
+}
+
+// Compile compiles the title expressions once, so that Match does not compile
+// them for every update. Expressions that do not compile are left out, and the
+// first of them is returned as the error.
+func (s *Selector) Compile() error {
+	var first error
+	s.titleRe = nil
+	for _, expr := range s.TitleRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			if first == nil {
+				first = fmt.Errorf("invalid title expression %q: %v", expr, err)
+			}
+			continue
+		}
+		s.titleRe = append(s.titleRe, re)
+	}
+	s.compiled = true
+	return first
+}
+
+// Empty returns true if the selector matches no update.
+func (s Selector) Empty() bool {
+	return len(s.CVEs) == 0 && len(s.Severities) == 0 && len(s.Categories) == 0 && len(s.TitleRegex) == 0
+}
+
+// Match returns true if u matches any value of the selector. Expressions that
+// do not compile match nothing; see Validate. A selector that was not compiled
+// compiles its expressions on every call, so callers matching many updates
+// should Compile it first.
+func (s Selector) Match(u *updates.Update) bool {
+	for _, c := range u.CveIDs {
+		if containsFold(s.CVEs, c) {
+			return true
+		}
+	}
+	if u.MsrcSeverity != "" && containsFold(s.Severities, u.MsrcSeverity) {
+		return true
+	}
+	if len(s.Categories) > 0 && u.InCategories(s.Categories) {
+		return true
+	}
+	if !s.compiled {
+		s.Compile()
+	}
+	for _, re := range s.titleRe {
+		if re.MatchString(u.Title) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if any title expression does not compile.
+func (s Selector) Validate() error {
+	return s.Compile()
+}
+
+func (s Selector) String() string {
+	var c []string
+	add := func(name string, values []string) {
+		if len(values) > 0 {
+			c = append(c, fmt.Sprintf("%s=%q", name, strings.Join(values, ",")))
+		}
+	}
+	add("cves", s.CVEs)
+	add("severity", s.Severities)
+	add("categories", s.Categories)
+	add("title-regex", s.TitleRegex)
+	return strings.Join(c, " ")
+}
+
+// RequiredSelector returns the selector of updates required by CVE, severity
+// or category.
+func (e Enforcements) RequiredSelector() Selector {
+	return Selector{CVEs: e.RequiredCVEs, Severities: e.RequiredSeverity, Categories: e.RequiredCategories}
+}
+
+// HiddenSelector returns the selector of updates hidden by title, compiled.
+// Get rejects the files with expressions that do not compile, so none are
+// left out of it.
+func (e Enforcements) HiddenSelector() Selector {
+	s := Selector{TitleRegex: e.HiddenTitleRegex}
+	s.Compile()
+	return s
+}
+
+func containsFold(s []string, e string) bool {
+	for _, v := range s {
+		if strings.EqualFold(v, e) {
+			return true
+		}
+	}
+	return false
+}
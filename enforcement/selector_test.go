@@ -0,0 +1,77 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"testing"
+
+	"github.com/google/cabbie/updates"
+)
+
+func TestSelectorMatch(t *testing.T) {
+	u := &updates.Update{
+		Title:        "2026-05 Cumulative Update Preview for Windows 11 (KB5031356)",
+		CveIDs:       []string{"CVE-2026-21001", "CVE-2026-21002"},
+		MsrcSeverity: "Critical",
+		Categories:   []updates.Category{{Name: "Security Updates"}},
+	}
+	tests := []struct {
+		desc string
+		s    Selector
+		want bool
+	}{
+		{"empty", Selector{}, false},
+		{"cve", Selector{CVEs: []string{"cve-2026-21002"}}, true},
+		{"other cve", Selector{CVEs: []string{"CVE-2026-99999"}}, false},
+		{"severity", Selector{Severities: []string{"Important", "critical"}}, true},
+		{"other severity", Selector{Severities: []string{"Moderate"}}, false},
+		{"category", Selector{Categories: []string{"Security Updates"}}, true},
+		{"other category", Selector{Categories: []string{"Drivers"}}, false},
+		{"title", Selector{TitleRegex: []string{`(?i)\bpreview\b`}}, true},
+		{"other title", Selector{TitleRegex: []string{`^Feature update`}}, false},
+		{"invalid title", Selector{TitleRegex: []string{`KB(5031356`}}, false},
+		{"any field", Selector{CVEs: []string{"CVE-2026-99999"}, Severities: []string{"Critical"}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Match(u); got != tt.want {
+			t.Errorf("Match(%s) = %t, want %t", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestSelectorValidate(t *testing.T) {
+	if err := (Selector{TitleRegex: []string{`(?i)preview`}}).Validate(); err != nil {
+		t.Errorf("Validate() returned unexpected error: %v", err)
+	}
+	if err := (Selector{TitleRegex: []string{`KB(5031356`}}).Validate(); err == nil {
+		t.Errorf("Validate() of an invalid expression returned nil, want error")
+	}
+}
+
+func TestSelectorCompile(t *testing.T) {
+	u := &updates.Update{Title: "2026-05 Cumulative Update Preview for Windows 11 (KB5031356)"}
+	s := Selector{TitleRegex: []string{`KB(5031356`, `(?i)\bpreview\b`}}
+	if err := s.Compile(); err == nil {
+		t.Errorf("Compile() of an invalid expression returned nil, want error")
+	}
+	if !s.Match(u) {
+		t.Errorf("Match() of a selector compiled with an invalid expression = false, want true")
+	}
+	// Match uses the compiled expressions rather than recompiling TitleRegex.
+	s.TitleRegex = []string{`^Feature update`}
+	if !s.Match(u) {
+		t.Errorf("Match() after Compile() recompiled the expressions")
+	}
+}
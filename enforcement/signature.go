@@ -0,0 +1,133 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SigExt is appended to the path of an enforcement file to name its detached
+// signature, e.g. required.json.sig.
+const SigExt = ".sig"
+
+var (
+	errSignature = errors.New("signature does not match any trusted key")
+	errUnsigned  = errors.New("file is not signed")
+
+	trustMu sync.Mutex
+	trust   Trust
+)
+
+// Trust configures the verification of enforcement files. A file with a
+// signature must be signed by one of Keys. Unsigned files are accepted unless
+// RequireSigned is set.
+type Trust struct {
+	Keys          []ed25519.PublicKey
+	RequireSigned bool
+}
+
+// SetTrust sets the keys and mode used to verify enforcement files.
+func SetTrust(t Trust) {
+	trustMu.Lock()
+	defer trustMu.Unlock()
+	trust = t
+}
+
+func currentTrust() Trust {
+	trustMu.Lock()
+	defer trustMu.Unlock()
+	return trust
+}
+
+// verify checks the signature of data. A nil sig means the data is unsigned.
+func (t Trust) verify(data, sig []byte) error {
+	if sig == nil {
+		if t.RequireSigned {
+			return errUnsigned
+		}
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature encoding: %v", errSignature, err)
+	}
+	for _, k := range t.Keys {
+		if ed25519.Verify(k, data, raw) {
+			return nil
+		}
+	}
+	return errSignature
+}
+
+// readSignature returns the detached signature of the file at path, or nil if
+// it has none.
+func readSignature(path string) ([]byte, error) {
+	sig, err := os.ReadFile(path + SigExt)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return sig, err
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key, as pinned in the config.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %q: %v", s, err)
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key %q: got %d bytes, want %d", s, len(b), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey decodes a base64 ed25519 private key seed, as written by
+// MarshalPrivateKey.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	if len(b) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key: got %d bytes, want %d", len(b), ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(b), nil
+}
+
+// MarshalPrivateKey encodes the seed of a private key in base64.
+func MarshalPrivateKey(k ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(k.Seed()) + "\n")
+}
+
+// MarshalPublicKey encodes a public key in base64, as pinned in the config.
+func MarshalPublicKey(k ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(k)
+}
+
+// Sign writes the detached signature of the file at path next to it.
+func Sign(k ed25519.PrivateKey, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(k, data)) + "\n"
+	return os.WriteFile(path+SigExt, []byte(sig), 0644)
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func TestSignedGet(t *testing.T) {
+	defer func(d string) { enforceDir = d }(enforceDir)
+	enforceDir = t.TempDir()
+	defer SetTrust(Trust{})
+	key, other := testKey(1), testKey(2)
+
+	files := map[string]ed25519.PrivateKey{
+		"signed.json":   key,
+		"unsigned.json": nil,
+		"forged.json":   other,
+	}
+	for name, k := range files {
+		p := filepath.Join(enforceDir, name)
+		if err := os.WriteFile(p, []byte(`{"required": ["4018073"]}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if k == nil {
+			continue
+		}
+		if err := Sign(k, p); err != nil {
+			t.Fatalf("Sign(%s) returned unexpected error: %v", name, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		desc     string
+		trust    Trust
+		wantErrs map[string]error
+	}{
+		{"signatures checked", Trust{Keys: []ed25519.PublicKey{key.Public().(ed25519.PublicKey)}}, map[string]error{
+			"forged.json": errSignature,
+		}},
+		{"signatures required", Trust{Keys: []ed25519.PublicKey{key.Public().(ed25519.PublicKey)}, RequireSigned: true}, map[string]error{
+			"forged.json":   errSignature,
+			"unsigned.json": errUnsigned,
+		}},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			SetTrust(tt.trust)
+			_, fileErrs, err := Get()
+			if err != nil {
+				t.Fatalf("Get() returned unexpected error: %v", err)
+			}
+			if len(fileErrs) != len(tt.wantErrs) {
+				t.Errorf("Get() returned file errors %v, want %d", fileErrs, len(tt.wantErrs))
+			}
+			for _, fe := range fileErrs {
+				if want := tt.wantErrs[filepath.Base(fe.Path)]; want == nil || !errors.Is(fe, want) {
+					t.Errorf("Get() returned file error %v, want %v", fe, want)
+				}
+			}
+			if got := Validate(filepath.Join(enforceDir, "forged.json")); len(got) != 1 {
+				t.Errorf("Validate(forged.json) returned %v, want one problem", got)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key := testKey(3)
+	got, err := ParsePrivateKey(MarshalPrivateKey(key))
+	if err != nil || !got.Equal(key) {
+		t.Errorf("ParsePrivateKey(MarshalPrivateKey()) = %v, %v, want %v", got, err, key)
+	}
+	pub := key.Public().(ed25519.PublicKey)
+	gotPub, err := ParsePublicKey(MarshalPublicKey(pub))
+	if err != nil || !gotPub.Equal(pub) {
+		t.Errorf("ParsePublicKey(MarshalPublicKey()) = %v, %v, want %v", gotPub, err, pub)
+	}
+	for _, s := range []string{"not base64!", "c2hvcnQ="} {
+		if _, err := ParsePublicKey(s); err == nil {
+			t.Errorf("ParsePublicKey(%q) returned nil error, want error", s)
+		}
+	}
+}
+
+func TestRemoteFetchSigned(t *testing.T) {
+	defer func(d string) { enforceDir = d }(enforceDir)
+	enforceDir = t.TempDir()
+	defer SetTrust(Trust{})
+	key := testKey(4)
+	SetTrust(Trust{Keys: []ed25519.PublicKey{key.Public().(ed25519.PublicKey)}, RequireSigned: true})
+
+	doc := []byte(`{"hidden": ["5031356"]}`)
+	var sig []byte
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/enforcement.json":
+			w.Write(doc)
+		case "/enforcement.json" + SigExt:
+			if sig == nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(sig)
+		}
+	}))
+	defer ts.Close()
+	r := &Remote{URL: ts.URL + "/enforcement.json", Client: ts.Client()}
+
+	if _, err := r.Fetch(context.Background()); !errors.Is(err, errUnsigned) {
+		t.Errorf("Fetch() of an unsigned document returned error %v, want %v", err, errUnsigned)
+	}
+	if _, err := os.Stat(remoteCache()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Fetch() of an unsigned document cached it")
+	}
+
+	p := filepath.Join(t.TempDir(), "enforcement.json")
+	if err := os.WriteFile(p, doc, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Sign(key, p); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	if sig, err = os.ReadFile(p + SigExt); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := r.Fetch(context.Background()); !changed || err != nil {
+		t.Errorf("Fetch() of a signed document = %t, %v, want true, nil", changed, err)
+	}
+	got, fileErrs, err := Get()
+	if err != nil || len(fileErrs) > 0 || len(got.Hidden) != 1 {
+		t.Errorf("Get() = %v, %v, %v, want the signed remote document", got.Hidden, fileErrs, err)
+	}
+}
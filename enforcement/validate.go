@@ -0,0 +1,192 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Problem is an issue found while validating an enforcement file. Line and
+// Column are 1-based, and zero if the problem has no position.
+type Problem struct {
+	Path   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.Path, p.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", p.Path, p.Line, p.Column, p.Msg)
+}
+
+// Dir returns the directory enforcement files are read from.
+func Dir() string {
+	return enforceDir
+}
+
+// Validate checks an enforcement file without applying it. Unlike Get, which
+// ignores keys it does not know, Validate reports them, along with the
+// position of any syntax or type error.
+func Validate(path string) []Problem {
+	path = filepath.Clean(path)
+	if filepath.Ext(path) != ".json" {
+		return []Problem{{Path: path, Msg: errFileType.Error()}}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []Problem{{Path: path, Msg: err.Error()}}
+	}
+
+	var e file
+	if err := json.Unmarshal(data, &e); err != nil {
+		p := Problem{Path: path, Msg: err.Error()}
+		var se *json.SyntaxError
+		var te *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &se):
+			// The offset is just past the offending byte.
+			p.Line, p.Column = position(data, se.Offset-1)
+		case errors.As(err, &te):
+			p.Line, p.Column = position(data, te.Offset)
+		}
+		return []Problem{p}
+	}
+
+	var problems []Problem
+	if err := e.check(); err != nil {
+		problems = append(problems, Problem{Path: path, Msg: err.Error()})
+	}
+	if sig, err := readSignature(path); err != nil {
+		problems = append(problems, Problem{Path: path, Msg: err.Error()})
+	} else if err := currentTrust().verify(data, sig); err != nil {
+		problems = append(problems, Problem{Path: path, Msg: err.Error()})
+	}
+	w := &walker{path: path, data: data, dec: json.NewDecoder(bytes.NewReader(data)), problems: problems}
+	if err := w.walk(reflect.TypeOf(e), ""); err != nil && err != io.EOF {
+		w.problems = append(w.problems, Problem{Path: path, Msg: err.Error()})
+	}
+	return w.problems
+}
+
+// position converts a byte offset in data to a 1-based line and column.
+func position(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// jsonFields maps the JSON keys of a struct type to their field types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		// Untagged embedded structs are flattened, as encoding/json does.
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for k, v := range jsonFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// walker reports object keys of a JSON document that have no matching field.
+type walker struct {
+	path     string
+	data     []byte
+	dec      *json.Decoder
+	problems []Problem
+}
+
+// walk reads the next JSON value, checking object keys against the fields of
+// t. Values without a struct type are not checked.
+func (w *walker) walk(t reflect.Type, where string) error {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	switch d {
+	case '{':
+		var fields map[string]reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = jsonFields(t)
+		}
+		for w.dec.More() {
+			// The key starts at the first quote after the previous token.
+			off := w.dec.InputOffset()
+			off += int64(bytes.IndexByte(w.data[off:], '"'))
+			k, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := k.(string)
+			ft, known := fields[key]
+			if fields != nil && !known {
+				l, c := position(w.data, off)
+				w.problems = append(w.problems, Problem{Path: w.path, Line: l, Column: c, Msg: fmt.Sprintf("unknown key %q%s", key, where)})
+			}
+			if err := w.walk(ft, fmt.Sprintf(" in %q", key)); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var et reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			et = t.Elem()
+		}
+		for w.dec.More() {
+			if err := w.walk(et, where); err != nil {
+				return err
+			}
+		}
+	}
+	// Consume the closing delimiter.
+	_, err = w.dec.Token()
+	return err
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Problem
+	}{
+		{"required.json", nil},
+		{"excluded-drivers.json", nil},
+		{"scheduled.json", nil},
+		{"selectors.json", nil},
+		{"bad-driver-date.json", []Problem{{Msg: `excluded-drivers: invalid driver-date-before "12/31/2025", want YYYY-MM-DD`}}},
+		{"bad-regex.json", []Problem{{Msg: "invalid title expression \"KB(5031356\": error parsing regexp: missing closing ): `KB(5031356`"}}},
+		{"bad-install-by.json", []Problem{{Msg: "uninstall: 5031356 sets install-by, which only applies to required updates"}}},
+		{"bad-override-blackout.json", []Problem{{Msg: "hidden: 5031356 sets override-blackout, which only applies to required and uninstall entries"}}},
+		{"unknown-key.json", []Problem{
+			{Line: 3, Column: 3, Msg: `unknown key "hiden"`},
+			{Line: 5, Column: 6, Msg: `unknown key "driver-klass" in "excluded-drivers"`},
+		}},
+		{"invalid.json", []Problem{{Line: 1, Column: 1}}},
+		{"wrong-type.json", []Problem{{Line: 2}}},
+		{"missing.json", []Problem{{}}},
+		{"wrong-filetype.txt", []Problem{{Msg: errFileType.Error()}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := Validate(filepath.Join(testData, tt.in))
+			// Only compare messages where the test sets one.
+			for i := range got {
+				got[i].Path = ""
+				if i < len(tt.want) && tt.want[i].Msg == "" {
+					got[i].Msg = ""
+				}
+				if i < len(tt.want) && tt.want[i].Column == 0 {
+					got[i].Column = 0
+				}
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Validate(%s) returned unexpected diff (-want +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	data := []byte("{\n  \"a\": 1\n}")
+	for _, tt := range []struct {
+		offset            int64
+		wantLine, wantCol int
+	}{
+		{0, 1, 1},
+		{2, 2, 1},
+		{4, 2, 3},
+		{100, 3, 2},
+	} {
+		l, c := position(data, tt.offset)
+		if l != tt.wantLine || c != tt.wantCol {
+			t.Errorf("position(%d) = %d:%d, want %d:%d", tt.offset, l, c, tt.wantLine, tt.wantCol)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/fsnotify.v1"
+)
+
+// Debounce is how long Watcher waits for the enforcement directory to settle
+// before reporting a change. Editors and configuration management tools often
+// write a file several times when saving it.
+const Debounce = 2 * time.Second
+
+var errWatcherClosed = errors.New("filesystem watcher closed")
+
+// Change is a batch of changes to enforcement files.
+type Change struct {
+	// Paths lists the files that changed, sorted.
+	Paths []string
+	// Removed is true if any of the files was deleted or renamed away, which
+	// withdraws its rules.
+	Removed bool
+}
+
+func (c Change) String() string {
+	if c.Removed {
+		return fmt.Sprintf("%q (removed)", c.Paths)
+	}
+	return fmt.Sprintf("%q", c.Paths)
+}
+
+// Watcher runs a filesystem watcher on the enforcement directory, so that
+// required updates are installed as soon as they are configured. Changes are
+// sent to changes once no further event arrives for Debounce. All configured
+// required updates are also read on a schedule (see the cabbie.go
+// t.Enforcement ticker) in case a filesystem event is missed.
+//
+// Watcher runs until ctx is done, when it returns ctx.Err(), or until the
+// watcher fails, when it returns the error and may be restarted.
+func Watcher(ctx context.Context, changes chan<- Change) error {
+	return watch(ctx, enforceDir, Debounce, changes)
+}
+
+// watch reports changes to the enforcement files in dir, see Watcher.
+func watch(ctx context.Context, dir string, delay time.Duration, changes chan<- Change) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("enforce: error creating %q:\n%v", dir, err)
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("enforce: error creating filesystem watcher:\n%v", err)
+	}
+	defer fsw.Close()
+	if err := fsw.Add(dir); err != nil {
+		return fmt.Errorf("enforce: error adding %q to filesystem watcher:\n%v", dir, err)
+	}
+
+	// The timer only runs while changes are pending.
+	timer := time.NewTimer(delay)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+	pending := make(map[string]bool)
+	removed := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return fmt.Errorf("enforce: %w", errWatcherClosed)
+			}
+			return fmt.Errorf("enforce: filesystem watcher error:\n%v", err)
+		case evt, ok := <-fsw.Events:
+			if !ok {
+				return fmt.Errorf("enforce: %w", errWatcherClosed)
+			}
+			gone := evt.Op&(fsnotify.Remove|fsnotify.Rename) != 0
+			if gone && filepath.Clean(evt.Name) == filepath.Clean(dir) {
+				return fmt.Errorf("enforce: %q was removed", dir)
+			}
+			if !watched(evt) {
+				continue
+			}
+			pending[evt.Name] = true
+			removed = removed || gone
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(delay)
+		case <-timer.C:
+			c := Change{Removed: removed}
+			for p := range pending {
+				c.Paths = append(c.Paths, p)
+			}
+			sort.Strings(c.Paths)
+			select {
+			case changes <- c:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			pending = make(map[string]bool)
+			removed = false
+		}
+	}
+}
+
+// watched returns true if evt may change the enforcements. Editor swap and
+// backup files, and attribute changes, are ignored.
+func watched(evt fsnotify.Event) bool {
+	if evt.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+		return false
+	}
+	switch filepath.Ext(evt.Name) {
+	case ".json", SigExt:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcement
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// startWatch runs watch on a new directory until the test ends.
+func startWatch(t *testing.T) (string, <-chan Change) {
+	t.Helper()
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan Change)
+	done := make(chan error, 1)
+	go func() { done <- watch(ctx, dir, 100*time.Millisecond, changes) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// Give the watcher time to register the directory.
+	time.Sleep(100 * time.Millisecond)
+	return dir, changes
+}
+
+func nextChange(t *testing.T, changes <-chan Change) Change {
+	t.Helper()
+	select {
+	case c := <-changes:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch() reported no change")
+	}
+	return Change{}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	dir, changes := startWatch(t)
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	for i := 0; i < 5; i++ {
+		for _, p := range []string{a, b} {
+			if err := os.WriteFile(p, []byte(`{"required": ["4018073"]}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.json.swp"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := Change{Paths: []string{a, b}}
+	if diff := cmp.Diff(want, nextChange(t, changes)); diff != "" {
+		t.Errorf("watch() returned unexpected diff (-want +got):\n%s", diff)
+	}
+	select {
+	case c := <-changes:
+		t.Errorf("watch() reported a second change for one batch of writes: %v", c)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestWatchRemove(t *testing.T) {
+	dir, changes := startWatch(t)
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	if err := os.WriteFile(a, []byte(`{"required": ["4018073"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nextChange(t, changes)
+
+	if err := os.Rename(a, b); err != nil {
+		t.Fatal(err)
+	}
+	want := Change{Paths: []string{a, b}, Removed: true}
+	if diff := cmp.Diff(want, nextChange(t, changes)); diff != "" {
+		t.Errorf("watch() after rename returned unexpected diff (-want +got):\n%s", diff)
+	}
+
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	want = Change{Paths: []string{b}, Removed: true}
+	if diff := cmp.Diff(want, nextChange(t, changes)); diff != "" {
+		t.Errorf("watch() after remove returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWatchCancel(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watch(ctx, dir, time.Second, make(chan Change)) }()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("watch() after cancel returned %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch() did not return after cancel")
+	}
+}
+
+func TestWatchDirRemoved(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "enforcement")
+	done := make(chan error, 1)
+	go func() { done <- watch(context.Background(), dir, time.Second, make(chan Change)) }()
+	// Give the watcher time to create and register the directory.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("watch() after removing its directory returned nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch() did not return after its directory was removed")
+	}
+}
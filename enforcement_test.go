@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/enforcement"
+)
+
+func TestValidateEnforcement(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.json":   `{"required": ["4018073"]}`,
+		"typo.json":   `{"hiden": ["4018073"]}`,
+		"notes.txt":   `not an enforcement`,
+		"broken.json": `{"required": [}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	n, err := validateEnforcement(&b, dir)
+	if err != nil {
+		t.Fatalf("validateEnforcement(%q) returned unexpected error: %v", dir, err)
+	}
+	if n != 3 {
+		t.Errorf("validateEnforcement(%q) = %d problems, want 3:\n%s", dir, n, b.String())
+	}
+	for _, want := range []string{"good.json: OK", `typo.json:1:2: unknown key "hiden"`, "broken.json:1:"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("validateEnforcement(%q) output missing %q:\n%s", dir, want, b.String())
+		}
+	}
+}
+
+func TestShowEnforcement(t *testing.T) {
+	e := enforcement.Enforcements{
+		Required:   []string{"4018073"},
+		Hidden:     []string{"67891011"},
+		Conflicts:  []enforcement.Conflict{{ID: "67891011", Keys: []string{enforcement.KeyHidden, enforcement.KeyRequired}, Winner: enforcement.KeyHidden}},
+		InstallBy:  map[string]time.Time{"4018073": time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		NextChange: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC),
+	}
+	var b bytes.Buffer
+	if err := showEnforcement(&b, formatText, e, []enforcement.FileError{{Path: "broken.json", Err: errors.New("bad")}}); err != nil {
+		t.Fatalf("showEnforcement() returned unexpected error: %v", err)
+	}
+	for _, want := range []string{"required 4018073 (install by " + formatTime(e.InstallBy["4018073"]) + ")", "hidden 67891011", "67891011 is listed under hidden and required; hidden wins", "Next scheduled change: " + formatTime(e.NextChange), "broken.json: bad"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("showEnforcement() output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestSignEnforcement(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "enforcement.key")
+	var b bytes.Buffer
+	if err := keygen(&b, key); err != nil {
+		t.Fatalf("keygen(%q) returned unexpected error: %v", key, err)
+	}
+	if err := keygen(&b, key); err == nil {
+		t.Errorf("keygen(%q) overwrote an existing key", key)
+	}
+	doc := filepath.Join(dir, "required.json")
+	if err := os.WriteFile(doc, []byte(`{"required": ["4018073"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := signEnforcement(&b, key, []string{doc}); err != nil {
+		t.Fatalf("signEnforcement(%q) returned unexpected error: %v", doc, err)
+	}
+	if _, err := os.Stat(doc + enforcement.SigExt); err != nil {
+		t.Errorf("signEnforcement(%q) wrote no signature: %v", doc, err)
+	}
+	if err := signEnforcement(&b, filepath.Join(dir, "missing.key"), []string{doc}); err == nil {
+		t.Errorf("signEnforcement() with a missing key returned nil error")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/errors"
+	"github.com/google/cabbie/updatehistory"
+	"github.com/google/cabbie/updates"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVetFormat(t *testing.T) {
+	for _, tt := range []struct {
+		format  string
+		wantErr bool
+	}{
+		{formatJSON, false},
+		{formatCSV, false},
+		{"xml", true},
+	} {
+		if err := vetFormat(tt.format, formatJSON, formatCSV); (err != nil) != tt.wantErr {
+			t.Errorf("vetFormat(%q) returned error %v, want error %t", tt.format, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteUpdates(t *testing.T) {
+	required := []*updates.Update{{
+		Title:                    "Security Update",
+		Identity:                 updates.Identity{UpdateID: "sec", RevisionNumber: 2},
+		KBArticleIDs:             []string{"5031356"},
+		Categories:               []updates.Category{{Name: "Security Updates"}, {Name: "Windows 11"}},
+		CveIDs:                   []string{"CVE-2026-0001"},
+		MsrcSeverity:             "Critical",
+		MaxDownloadSize:          1024,
+		LastDeploymentChangeTime: time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC),
+	}}
+	optional := []*updates.Update{{Title: "Feature Pack", Identity: updates.Identity{UpdateID: "fp"}}}
+
+	var b bytes.Buffer
+	if err := writeUpdates(&b, formatCSV, required, optional); err != nil {
+		t.Fatalf("writeUpdates(csv) returned unexpected error: %v", err)
+	}
+	want := "required,title,kbs,update_id,revision,categories,msrc_severity,cve_ids,max_download_size,last_deployment_change_time\n" +
+		"true,Security Update,5031356,sec,2,Security Updates;Windows 11,Critical,CVE-2026-0001,1024,2026-10-13T00:00:00Z\n" +
+		"false,Feature Pack,,fp,0,,,,0,\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeUpdates(csv) returned unexpected diff (-want +got):\n%s", diff)
+	}
+
+	b.Reset()
+	if err := writeUpdates(&b, formatJSON, required, optional); err != nil {
+		t.Fatalf("writeUpdates(json) returned unexpected error: %v", err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) returned unexpected error: %v", b.String(), err)
+	}
+	if len(got) != 2 || got[0]["Required"] != true || got[0]["MsrcSeverity"] != "Critical" {
+		t.Errorf("writeUpdates(json) = %v, want the required update first with its fields", got)
+	}
+	if _, ok := got[0]["Item"]; ok {
+		t.Errorf("writeUpdates(json) included the IDispatch item: %v", got[0])
+	}
+}
+
+func TestWriteHistory(t *testing.T) {
+	entries := []*updatehistory.Entry{{
+		Operation:      updatehistory.Installation,
+		ResultCode:     4,
+		HResult:        int(errors.WU_E_INSTALL_NOT_ALLOWED),
+		Date:           time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC),
+		Title:          "KB5031356",
+		UpdateIdentity: updates.Identity{UpdateID: "sec", RevisionNumber: 1},
+	}}
+	var b bytes.Buffer
+	if err := writeHistory(&b, formatCSV, entries); err != nil {
+		t.Fatalf("writeHistory(csv) returned unexpected error: %v", err)
+	}
+	want := "date,operation,result,hresult,title,update_id,revision,categories\n" +
+		"2026-10-14T00:00:00Z,install,failed,WU_E_INSTALL_NOT_ALLOWED,KB5031356,sec,1,\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeHistory(csv) returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
@@ -9,6 +9,7 @@ require (
 	github.com/google/glazier v0.0.0-20210617205946-bf91b619f5d4
 	github.com/google/go-cmp v0.5.4
 	github.com/google/subcommands v1.2.0
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/scjalliance/comshim v0.0.0-20190308082608-cf06d2532c4e
 	golang.org/x/net v0.23.0
 	golang.org/x/sys v0.18.0
@@ -21,7 +22,6 @@ require (
 	github.com/google/logger v1.1.1 // indirect
 	github.com/iamacarpet/go-win64api v0.0.0-20210311141720-fe38760bed28 // indirect
 	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
-	github.com/robfig/cron/v3 v3.0.1 // indirect
 	github.com/StackExchange/wmi v1.2.0 // indirect
 	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
 )
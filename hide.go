@@ -18,12 +18,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"flag"
 	"github.com/google/cabbie/cablib"
-	"github.com/google/cabbie/search"
-	"github.com/google/cabbie/session"
-	"github.com/google/cabbie/updatecollection"
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/updates"
 	"github.com/google/deck"
 	"github.com/google/subcommands"
 )
@@ -61,44 +62,31 @@ func (c hideCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subco
 		return subcommands.ExitSuccess
 	}
 
-	if err := hide(kbs); err != nil {
+	if err := hide(kbs, enforcement.Selector{}); err != nil {
 		fmt.Println(err)
 	}
 	return subcommands.ExitSuccess
 }
 
-// TODO(cjgenevi): Turn into shared function that can be used by multiple actions
-func findUpdates(criteria string) (*updatecollection.Collection, error) {
-	// Start Windows update session
-	s, err := session.New()
-	if err != nil {
-		return nil, err
-	}
-	defer s.Close()
-
-	q, err := search.NewSearcher(s, criteria, config.WSUSServers, config.EnableThirdParty)
+func unhide(kbs KBSet) error {
+	// Find hidden updates.
+	a, err := newAgent()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer q.Close()
-
-	return q.QueryUpdates()
-}
+	defer a.Close()
 
-func unhide(kbs KBSet) error {
-	// Find hidden updates.
-	uc, err := findUpdates("IsHidden=1")
+	uc, err := a.Search("IsHidden=1")
 	if err != nil {
 		return err
 	}
-	defer uc.Close()
 
-	deck.InfofA("Found %d matching updates.", len(uc.Updates)).With(eventID(cablib.EvtUnhide)).Go()
+	deck.InfofA("Found %d matching updates.", len(uc)).With(eventID(cablib.EvtUnhide)).Go()
 
-	for _, u := range uc.Updates {
+	for _, u := range uc {
 		if kbs.Search(u.KBArticleIDs) {
 			deck.InfofA("Unhiding update:\n%s", u.Title).With(eventID(cablib.EvtUnhide)).Go()
-			if err := u.UnHide(); err != nil {
+			if err := a.UnHide(u); err != nil {
 				deck.ErrorfA("Failed to unhide update %s:\n %s", u.Title, err).With(eventID(cablib.EvtErrUnhide)).Go()
 			}
 		}
@@ -107,20 +95,26 @@ func unhide(kbs KBSet) error {
 	return nil
 }
 
-func hide(kbs KBSet) error {
+// hide hides the updates matching any of the KBs or the selector.
+func hide(kbs KBSet, sel enforcement.Selector) error {
 	// Find non-hidden updates that are installed or not installed.
-	uc, err := findUpdates("IsHidden=0 and IsInstalled=0 or IsHidden=0 and IsInstalled=1")
+	a, err := newAgent()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	uc, err := a.Search("IsHidden=0 and IsInstalled=0 or IsHidden=0 and IsInstalled=1")
 	if err != nil {
 		return err
 	}
-	defer uc.Close()
 
-	deck.InfofA("Found %d matching updates.", len(uc.Updates)).With(eventID(cablib.EvtHide)).Go()
+	deck.InfofA("Found %d matching updates.", len(uc)).With(eventID(cablib.EvtHide)).Go()
 
-	for _, u := range uc.Updates {
-		if kbs.Search(u.KBArticleIDs) {
+	for _, u := range uc {
+		if kbs.Search(u.KBArticleIDs) || sel.Match(u) {
 			deck.InfofA("Hiding update:\n%s", u.Title).With(eventID(cablib.EvtHide)).Go()
-			if err := u.Hide(); err != nil {
+			if err := a.Hide(u); err != nil {
 				deck.ErrorfA("Failed to hide update %s:\n %s", u.Title, err).With(eventID(cablib.EvtErrHide)).Go()
 			}
 		}
@@ -131,19 +125,24 @@ func hide(kbs KBSet) error {
 
 func hideByUpdateID(uuids []string) error {
 	// Find non-hidden updates that are installed or not installed.
-	uc, err := findUpdates("IsHidden=0 and IsInstalled=0 or IsHidden=0 and IsInstalled=1")
+	a, err := newAgent()
 	if err != nil {
 		return err
 	}
-	defer uc.Close()
+	defer a.Close()
 
-	deck.InfofA("Found %d matching updates.", len(uc.Updates)).With(eventID(cablib.EvtHide)).Go()
+	uc, err := a.Search("IsHidden=0 and IsInstalled=0 or IsHidden=0 and IsInstalled=1")
+	if err != nil {
+		return err
+	}
 
-	for _, u := range uc.Updates {
+	deck.InfofA("Found %d matching updates.", len(uc)).With(eventID(cablib.EvtHide)).Go()
+
+	for _, u := range uc {
 		for _, uuid := range uuids {
 			if uuid == u.Identity.UpdateID {
 				deck.InfofA("Hiding update by UpdateID:\n%s", u.Title).With(eventID(cablib.EvtHide)).Go()
-				if err := u.Hide(); err != nil {
+				if err := a.Hide(u); err != nil {
 					deck.ErrorfA("Failed to hide update %s:\n %s", u.Title, err).With(eventID(cablib.EvtErrHide)).Go()
 				}
 			}
@@ -152,3 +151,87 @@ func hideByUpdateID(uuids []string) error {
 
 	return nil
 }
+
+// hiddenByEnforcement returns true if an enforcement keeps u hidden, either
+// by hiding it or by uninstalling it. hidden is the HiddenSelector of e.
+func hiddenByEnforcement(u *updates.Update, e enforcement.Enforcements, hidden enforcement.Selector) bool {
+	if NewKBSetFromSlice(e.Hidden).Search(u.KBArticleIDs) || hidden.Match(u) {
+		return true
+	}
+	if NewKBSetFromSlice(e.Uninstall).Search(u.KBArticleIDs) {
+		return true
+	}
+	id := u.Identity.UpdateID
+	return cablib.StringInSlice(id, e.HiddenUpdateID) || cablib.StringInSlice(id, e.Uninstall)
+}
+
+// reconcileHidden makes the hidden updates match the enforcements. Updates an
+// enforcement hides are hidden and recorded, and recorded updates no
+// enforcement hides anymore are unhidden. Other hidden updates were hidden
+// outside of Cabbie; they are left alone and returned as drift.
+func reconcileHidden(e enforcement.Enforcements) ([]*updates.Update, error) {
+	prev, err := hiddenUpdates()
+	if err != nil {
+		return nil, err
+	}
+	recorded := make(map[string]bool)
+	for _, id := range prev {
+		recorded[id] = true
+	}
+
+	a, err := newAgent()
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+
+	visible, err := a.Search("IsHidden=0 and IsInstalled=0 or IsHidden=0 and IsInstalled=1")
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := a.Search("IsHidden=1")
+	if err != nil {
+		return nil, err
+	}
+
+	var keep []string
+	sel := e.HiddenSelector()
+	for _, u := range visible {
+		if !hiddenByEnforcement(u, e, sel) {
+			continue
+		}
+		deck.InfofA("Hiding update:\n%s", u.Title).With(eventID(cablib.EvtHide)).Go()
+		if err := a.Hide(u); err != nil {
+			deck.ErrorfA("Failed to hide update %s:\n %s", u.Title, err).With(eventID(cablib.EvtErrHide)).Go()
+			continue
+		}
+		keep = append(keep, u.Identity.UpdateID)
+	}
+	var drift []*updates.Update
+	for _, u := range hidden {
+		id := u.Identity.UpdateID
+		switch {
+		case hiddenByEnforcement(u, e, sel):
+			// Whoever hid it, the enforcement now decides when it is unhidden.
+			keep = append(keep, id)
+		case recorded[id]:
+			deck.InfofA("Unhiding update no longer hidden by enforcement:\n%s", u.Title).With(eventID(cablib.EvtUnhide)).Go()
+			if err := a.UnHide(u); err != nil {
+				deck.ErrorfA("Failed to unhide update %s:\n %s", u.Title, err).With(eventID(cablib.EvtErrUnhide)).Go()
+				keep = append(keep, id)
+			}
+		default:
+			drift = append(drift, u)
+		}
+	}
+	if len(drift) > 0 {
+		var titles []string
+		for _, u := range drift {
+			titles = append(titles, fmt.Sprintf("%s (%s)", u.Title, u.Identity.UpdateID))
+		}
+		deck.WarningfA("Found %d updates hidden outside of Cabbie enforcement:\n%s", len(drift), strings.Join(titles, "\n")).With(eventID(cablib.EvtHiddenDrift)).Go()
+	}
+	// Recorded updates that are no longer found are forgotten.
+	sort.Strings(keep)
+	return drift, setHiddenUpdates(keep)
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"testing"
+
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/updates"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReconcileHidden(t *testing.T) {
+	f := &agent.Fake{Updates: []*updates.Update{
+		{Title: "Newly Hidden", Identity: updates.Identity{UpdateID: "new"}, KBArticleIDs: []string{"5031356"}},
+		{Title: "Still Hidden", Identity: updates.Identity{UpdateID: "still"}, KBArticleIDs: []string{"5031357"}, IsHidden: true},
+		{Title: "No Longer Hidden", Identity: updates.Identity{UpdateID: "stale"}, KBArticleIDs: []string{"5031358"}, IsHidden: true},
+		{Title: "Hidden By Hand", Identity: updates.Identity{UpdateID: "manual"}, KBArticleIDs: []string{"5031359"}, IsHidden: true},
+		{Title: "Rolled Back", Identity: updates.Identity{UpdateID: "rolled-back"}, IsInstalled: true},
+		{Title: "Visible", Identity: updates.Identity{UpdateID: "visible"}, KBArticleIDs: []string{"5031360"}},
+	}}
+	defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+	newAgent = func() (agent.Agent, error) { return f, nil }
+	defer func(l func() ([]string, error), s func([]string) error) { hiddenUpdates, setHiddenUpdates = l, s }(hiddenUpdates, setHiddenUpdates)
+	hiddenUpdates = func() ([]string, error) { return []string{"still", "stale", "gone"}, nil }
+	var saved []string
+	setHiddenUpdates = func(ids []string) error {
+		saved = ids
+		return nil
+	}
+
+	e := enforcement.Enforcements{Hidden: []string{"5031356", "5031357"}, Uninstall: []string{"rolled-back"}}
+	drift, err := reconcileHidden(e)
+	if err != nil {
+		t.Fatalf("reconcileHidden() returned unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"new", "rolled-back"}, f.Hidden); diff != "" {
+		t.Errorf("reconcileHidden() hid unexpected updates (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"stale"}, f.Unhidden); diff != "" {
+		t.Errorf("reconcileHidden() unhid unexpected updates (-want +got):\n%s", diff)
+	}
+	if len(drift) != 1 || drift[0].Identity.UpdateID != "manual" {
+		t.Errorf("reconcileHidden() = %v, want drift of the update hidden by hand", drift)
+	}
+	if diff := cmp.Diff([]string{"new", "rolled-back", "still"}, saved); diff != "" {
+		t.Errorf("reconcileHidden() recorded unexpected updates (-want +got):\n%s", diff)
+	}
+}
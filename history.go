@@ -16,65 +16,188 @@ package main
 import (
 	"golang.org/x/net/context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"flag"
+	"github.com/google/cabbie/agent"
 	"github.com/google/cabbie/cablib"
-	"github.com/google/cabbie/search"
-	"github.com/google/cabbie/session"
 	"github.com/google/cabbie/updatehistory"
 	"github.com/google/deck"
 	"github.com/google/subcommands"
+	"github.com/google/glazier/go/helpers"
 )
 
 // Available flags
 type historyCmd struct {
+	format     string
+	since      string
+	until      string
+	kbs        string
+	updateIDs  string
+	operation  string
+	result     string
+	categories string
+	limit      int
+}
+
+// historyRecord is the machine-readable form of an update history entry.
+type historyRecord struct {
+	*updatehistory.Entry
+	OperationName      string
+	ResultName         string
+	HResultName        string
+	HResultDescription string
 }
 
 func (historyCmd) Name() string     { return "history" }
 func (historyCmd) Synopsis() string { return "Get a list of all the installed updates on the device." }
 func (historyCmd) Usage() string {
-	return fmt.Sprintf("%s history\n", filepath.Base(os.Args[0]))
+	return fmt.Sprintf("%s history [--since=<date>] [--until=<date>] [--kbs=<KBs>] [--update_id=<IDs>] "+
+		"[--operation=install|uninstall] [--result=failed|succeeded|aborted] [--category=<names>] [--limit=<n>] "+
+		"[--format=text|json|csv|table]\n", filepath.Base(os.Args[0]))
+}
+func (c *historyCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.format, "format", formatText, "output format, one of text, json, csv or table.")
+	f.StringVar(&c.since, "since", "", "only show entries on or after this date, as YYYY-MM-DD or RFC 3339.")
+	f.StringVar(&c.until, "until", "", "only show entries on or before this date, as YYYY-MM-DD or RFC 3339.")
+	f.StringVar(&c.kbs, "kbs", "", "comma separated list of KBs to show.")
+	f.StringVar(&c.updateIDs, "update_id", "", "comma separated list of UpdateIDs to show.")
+	f.StringVar(&c.operation, "operation", "", "only show install or uninstall entries.")
+	f.StringVar(&c.result, "result", "", "only show entries with this result, e.g. failed, succeeded or aborted.")
+	f.StringVar(&c.categories, "category", "", "comma separated list of update categories to show.")
+	f.IntVar(&c.limit, "limit", 0, "only show the most recent matching entries, if greater than zero.")
+}
+
+// filter builds the history filter from the command flags.
+func (c *historyCmd) filter() (updatehistory.Filter, error) {
+	f := updatehistory.Filter{
+		KBs:        helpers.StringToSlice(c.kbs),
+		UpdateIDs:  helpers.StringToSlice(c.updateIDs),
+		Categories: helpers.StringToSlice(c.categories),
+		Operation:  c.operation,
+		Result:     c.result,
+		Limit:      c.limit,
+	}
+	var err error
+	if f.Since, err = parseDate(c.since, false); err != nil {
+		return f, fmt.Errorf("invalid --since: %v", err)
+	}
+	if f.Until, err = parseDate(c.until, true); err != nil {
+		return f, fmt.Errorf("invalid --until: %v", err)
+	}
+	return f, f.Validate()
+}
+
+// parseDate parses an RFC 3339 time or a local date. A date only is the
+// start of that day, or its end if endOfDay is set.
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
 }
-func (c *historyCmd) SetFlags(f *flag.FlagSet) {}
 
 func (c *historyCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subcommands.ExitStatus {
-	h, err := history()
+	if err := vetFormat(c.format, formatText, formatJSON, formatCSV, formatTable); err != nil {
+		fmt.Printf("%v\n%s\nUsage: %s\n", err, c.Synopsis(), c.Usage())
+		return subcommands.ExitUsageError
+	}
+	filter, err := c.filter()
+	if err != nil {
+		fmt.Printf("%v\n%s\nUsage: %s\n", err, c.Synopsis(), c.Usage())
+		return subcommands.ExitUsageError
+	}
+	a, err := newAgent()
 	if err != nil {
 		fmt.Printf("Failed to get update history: %s", err)
 		deck.ErrorfA("Failed to get Update history: %s", err).With(eventID(cablib.EvtErrHistory)).Go()
 		return subcommands.ExitFailure
 	}
-	defer h.Close()
+	defer a.Close()
 
-	// Print entries sorted by date
-	sort.Slice(h.Entries, func(i, j int) bool {
-		return h.Entries[i].Date.Before(h.Entries[j].Date)
-	})
+	entries, err := history(a, filter)
+	if err != nil {
+		fmt.Printf("Failed to get update history: %s", err)
+		deck.ErrorfA("Failed to get Update history: %s", err).With(eventID(cablib.EvtErrHistory)).Go()
+		return subcommands.ExitFailure
+	}
 
-	for _, e := range h.Entries {
+	if c.format != formatText {
+		if err := writeHistory(os.Stdout, c.format, entries); err != nil {
+			fmt.Printf("Failed to write update history: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		return subcommands.ExitSuccess
+	}
+	for _, e := range entries {
 		fmt.Printf("Installed update:\n%v\n\n", e)
 	}
 	return subcommands.ExitSuccess
 }
 
-func history() (*updatehistory.History, error) {
-	// Start Windows update session
-	s, err := session.New()
-	if err != nil {
-		return nil, err
+func writeHistory(w io.Writer, format string, entries []*updatehistory.Entry) error {
+	records := []historyRecord{}
+	var rows [][]string
+	for _, e := range entries {
+		hr := e.HResultCode()
+		r := historyRecord{
+			Entry:              e,
+			OperationName:      e.OperationName(),
+			ResultName:         e.ResultName(),
+			HResultName:        hr.ErrorName(),
+			HResultDescription: hr.ErrorDesc(),
+		}
+		records = append(records, r)
+		name := r.HResultName
+		if name == "" {
+			name = fmt.Sprintf("0x%08X", int64(hr))
+		}
+		var cats []string
+		for _, c := range e.Categories {
+			cats = append(cats, c.Name)
+		}
+		rows = append(rows, []string{
+			formatTime(e.Date),
+			r.OperationName,
+			r.ResultName,
+			name,
+			e.Title,
+			e.UpdateIdentity.UpdateID,
+			strconv.Itoa(e.UpdateIdentity.RevisionNumber),
+			strings.Join(cats, ";"),
+		})
 	}
-	defer s.Close()
+	header := []string{"date", "operation", "result", "hresult", "title", "update_id", "revision", "categories"}
+	return writeRecords(w, format, records, header, rows)
+}
 
-	// Create Update searcher interface
-	searcher, err := search.NewSearcher(s, "", config.WSUSServers, config.EnableThirdParty)
+// history returns the entries matching the filter sorted by date. The agent
+// reads the history most recent first, so a filter limit keeps the most recent
+// entries and a since bound stops the search early.
+func history(a agent.Agent, f updatehistory.Filter) ([]*updatehistory.Entry, error) {
+	deck.InfoA("Collecting installed updates...").With(eventID(cablib.EvtHistory)).Go()
+	entries, err := a.History(f)
 	if err != nil {
 		return nil, err
 	}
-	defer searcher.Close()
-
-	deck.InfoA("Collecting installed updates...").With(eventID(cablib.EvtHistory)).Go()
-	return updatehistory.Get(searcher)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+	return entries, nil
 }
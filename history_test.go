@@ -0,0 +1,73 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/updatehistory"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHistory(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	f := &agent.Fake{Entries: []*updatehistory.Entry{
+		{Operation: updatehistory.Installation, ResultCode: 4, Date: day.AddDate(0, 0, 2), Title: "Update (KB1)"},
+		{Operation: updatehistory.Installation, ResultCode: 2, Date: day.AddDate(0, 0, 1), Title: "Update (KB2)"},
+		{Operation: updatehistory.Installation, ResultCode: 4, Date: day, Title: "Update (KB1)"},
+		{Operation: updatehistory.Installation, ResultCode: 4, Date: day.AddDate(0, 0, 3), Title: "Update (KB3)"},
+	}}
+	for _, tt := range []struct {
+		desc string
+		cmd  historyCmd
+		want []string
+	}{
+		{"all", historyCmd{}, []string{"2026-10-01", "2026-10-02", "2026-10-03", "2026-10-04"}},
+		{"last failure of a KB", historyCmd{kbs: "KB1", result: "failed", limit: 1}, []string{"2026-10-03"}},
+		{"date range", historyCmd{since: "2026-10-02T00:00:00Z", until: "2026-10-03T00:00:00Z"}, []string{"2026-10-02", "2026-10-03"}},
+		{"limit", historyCmd{limit: 2}, []string{"2026-10-03", "2026-10-04"}},
+	} {
+		filter, err := tt.cmd.filter()
+		if err != nil {
+			t.Fatalf("filter(%s) returned unexpected error: %v", tt.desc, err)
+		}
+		entries, err := history(f, filter)
+		if err != nil {
+			t.Fatalf("history(%s) returned unexpected error: %v", tt.desc, err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Date.Format("2006-01-02"))
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("history(%s) returned unexpected diff (-want +got):\n%s", tt.desc, diff)
+		}
+	}
+}
+
+func TestHistoryFilterInvalid(t *testing.T) {
+	for _, c := range []historyCmd{
+		{since: "yesterday"},
+		{until: "2026-13-01"},
+		{operation: "remove"},
+		{result: "broken"},
+		{limit: -1},
+	} {
+		if _, err := c.filter(); err == nil {
+			t.Errorf("filter(%+v) returned nil error, want error", c)
+		}
+	}
+}
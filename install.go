@@ -26,12 +26,13 @@ import (
 
 	"flag"
 	"github.com/google/cabbie/notification"
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/blackout"
 	"github.com/google/cabbie/cablib"
-	"github.com/google/cabbie/download"
-	"github.com/google/cabbie/install"
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/policy"
 	"github.com/google/cabbie/search"
-	"github.com/google/cabbie/session"
-	"github.com/google/cabbie/updatecollection"
+	"github.com/google/cabbie/updates"
 	"github.com/google/deck"
 	"github.com/google/aukera/client"
 	"github.com/google/subcommands"
@@ -42,18 +43,19 @@ import (
 type installCmd struct {
 	all, drivers, deadlineOnly, Interactive, virusDef bool
 	kbs                                               string
-}
-
-type installRsp struct {
-	hResult        string
-	resultCode     int
-	rebootRequired bool
+	// overdue marks installs forced by an enforcement install-by deadline.
+	overdue bool
+	// overrideBlackout installs, and reboots, even during a blackout period.
+	overrideBlackout bool
+	// selector adds the updates required by enforcement selectors to kbs, and
+	// hidden excludes the updates hidden by them.
+	selector, hidden enforcement.Selector
 }
 
 func (installCmd) Name() string     { return "install" }
 func (installCmd) Synopsis() string { return "Install selected available updates." }
 func (installCmd) Usage() string {
-	return fmt.Sprintf("%s install [--drivers | --virusDef | --kbs=\"<KBNumber>\" | --all]\n", filepath.Base(os.Args[0]))
+	return fmt.Sprintf("%s install [--drivers | --virusDef | --kbs=\"<KBNumber>\" | --all] [--override_blackout]\n", filepath.Base(os.Args[0]))
 }
 
 func (i *installCmd) SetFlags(f *flag.FlagSet) {
@@ -65,6 +67,7 @@ func (i *installCmd) SetFlags(f *flag.FlagSet) {
 
 	// Behavior Flags
 	f.BoolVar(&i.deadlineOnly, "deadlineOnly", false, fmt.Sprintf("Install available updates older than %d days", config.Deadline))
+	f.BoolVar(&i.overrideBlackout, "override_blackout", false, "Install, and reboot if required, even during a blackout period.")
 }
 
 var (
@@ -92,6 +95,10 @@ func (i installCmd) Execute(ctx context.Context, flags *flag.FlagSet, _ ...any)
 	if err := vetFlags(i); err != nil {
 		return subcommands.ExitUsageError
 	}
+	if p, ok := i.blackout(time.Now()); ok {
+		fmt.Printf("Blackout period %s is in effect; pass --override_blackout to install anyway.\n", p)
+		return subcommands.ExitFailure
+	}
 
 	if err := i.installUpdates(ctx); err != nil {
 		fmt.Printf("Failed to install updates: %v", err)
@@ -126,9 +133,9 @@ func (i *installCmd) criteria() (string, []string) {
 		c = fmt.Sprintf("%s AND CategoryIDs contains '%s'", search.BasicSearch, search.DefinitionUpdates)
 		rc = append(rc, "Definition Updates")
 		deck.InfofA("Starting search for virus definitions:\n%s", c).With(eventID(cablib.EvtSearch)).Go()
-	case i.kbs != "":
+	case i.kbs != "" || !i.selector.Empty():
 		c = search.BasicSearch
-		deck.InfofA("Starting search for KB's %q:\n%s", i.kbs, c).With(eventID(cablib.EvtSearch)).Go()
+		deck.InfofA("Starting search for KB's %q and enforcement selectors %q:\n%s", i.kbs, i.selector, c).With(eventID(cablib.EvtSearch)).Go()
 	default:
 		c = search.BasicSearch + " AND IsHidden=0 OR Type='Driver'"
 		rc = config.RequiredCategories
@@ -137,6 +144,120 @@ func (i *installCmd) criteria() (string, []string) {
 	return c, rc
 }
 
+// titles returns the titles of a list of updates.
+func titles(us []*updates.Update) []string {
+	var t []string
+	for _, u := range us {
+		t = append(t, u.Title)
+	}
+	return t
+}
+
+// decisions searches for updates and decides which of them to install. It
+// also returns the required categories used for the decisions.
+func (i *installCmd) decisions(a agent.Agent) ([]policy.Decision, []string, error) {
+	criteria, rc := i.criteria()
+
+	uc, err := a.Search(criteria)
+	if er := searchHResult.Set(a.SearchHResult()); er != nil {
+		deck.ErrorfA("Error posting metric:\n%v", er).With(eventID(cablib.EvtErrMetricReport)).Go()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encountered when attempting to query for updates: %v", err)
+	}
+	if len(uc) == 0 {
+		return nil, rc, nil
+	}
+	deck.InfofA("Updates Found:\n%s", strings.Join(titles(uc), "\n\n")).With(eventID(cablib.EvtUpdatesFound)).Go()
+
+	if err := initDriverExclusion(); err != nil {
+		deck.ErrorfA("Error initializing driver exclusions:\n%v", err).With(eventID(cablib.EvtErrDriverExclusion)).Go()
+	}
+	e := excludedDrivers.get()
+	e.HiddenTitleRegex = i.hidden.TitleRegex
+
+	return policy.Evaluate(uc, i.selection(rc), e), rc, nil
+}
+
+// selection returns the policy settings used to select updates to install.
+func (i *installCmd) selection(rc []string) policy.Settings {
+	return policy.Settings{
+		RequiredCategories: rc,
+		KBs:                NewKBSet(i.kbs),
+		Select:             i.selector,
+		DeadlineOnly:       i.deadlineOnly,
+		Deadline:           time.Duration(config.Deadline) * 24 * time.Hour,
+		Ring:               config.deploymentRing(),
+		PatchDelay:         config.patchDelay(),
+		SkipPreviews:       config.SkipPreviewReleases == 1,
+		Now:                time.Now(),
+	}
+}
+
+// logDecision records why an update is or is not being installed.
+func (i *installCmd) logDecision(d policy.Decision, rc []string) {
+	u := d.Update
+	switch d.Reason {
+	case policy.DriverExcluded:
+		deck.InfofA("Driver update %q excluded by rule: %s", u.Title, d.Rule).With(eventID(cablib.EvtDriverUpdateExcluded)).Go()
+	case policy.CategoryMismatch:
+		deck.InfofA("Skipping update %s.\nRequiredClassifications:\n%v\nUpdate classifications:\n%v",
+			u.Title,
+			rc,
+			u.Categories).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.KBNotRequested:
+		deck.InfofA("Skipping update %s.\nRequired KBs:\n%s\nUpdate KBs:\n%v",
+			u.Title,
+			NewKBSet(i.kbs),
+			u.KBArticleIDs).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.NotSelected:
+		deck.InfofA("Skipping update %s.\nRequired KBs:\n%s\nEnforcement selectors:\n%s\nUpdate KBs:\n%v\nUpdate CVEs:\n%v\nUpdate severity: %s",
+			u.Title,
+			NewKBSet(i.kbs),
+			i.selector,
+			u.KBArticleIDs,
+			u.CveIDs,
+			u.MsrcSeverity).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.HiddenBySelector:
+		deck.InfofA("Skipping update %s.\nHidden by enforcement selectors:\n%s", u.Title, i.hidden).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.DriverMaintenanceOnly:
+		deck.InfofA(
+			"Skipping driver %s with class %s and date version %s.\nDrivers are only installed during a maintenance window at this time.",
+			u.Title,
+			u.DriverClass,
+			u.DriverVerDate).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.RingDeferred:
+		deck.InfofA(
+			"Skipping update %s.\nUpdate deployed on %v is deferred by the deployment ring until %v.",
+			u.Title,
+			u.LastDeploymentChangeTime,
+			d.Deadline).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.PatchTuesdayDeferred:
+		deck.InfofA(
+			"Skipping update %s.\nUpdate deployed on %v is held until %d days after Patch Tuesday, %v.",
+			u.Title,
+			u.LastDeploymentChangeTime,
+			config.InstallAfterPatchTuesdayDays,
+			d.Deadline).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.PreviewRelease:
+		deck.InfofA("Skipping preview release %s.", u.Title).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.DeadlineNotReached:
+		deck.InfofA(
+			"Skipping update %s.\nUpdate deployed on %v has not reached the %d day threshold, due %v.",
+			u.Title,
+			u.LastDeploymentChangeTime,
+			config.Deadline,
+			d.Deadline).With(eventID(cablib.EvtUpdateSkip)).Go()
+	case policy.DeadlineReached:
+		deck.InfofA(
+			"Update %s deployed on %v has exceeded the %d day threshold, due %v.",
+			u.Title,
+			u.LastDeploymentChangeTime,
+			config.Deadline,
+			d.Deadline).With(eventID(cablib.EvtUpdatesFound)).Go()
+	}
+}
+
 func installingMessage() {
 	deck.InfoA("Cabbie is installing new updates.").With(eventID(cablib.EvtInstall)).Go()
 
@@ -153,20 +274,6 @@ func rebootMessage(t time.Time) {
 	}
 }
 
-func downloadCollection(s *session.UpdateSession, c *updatecollection.Collection) (int, error) {
-	d, err := download.NewDownloader(s, c)
-	if err != nil {
-		return 0, fmt.Errorf("error creating downloader:\n %v", err)
-	}
-	defer d.Close()
-
-	if err := d.Download(); err != nil {
-		return 0, fmt.Errorf("error downloading updates:\n %v", err)
-	}
-
-	return d.ResultCode()
-}
-
 // fetchDetailedUpdateError queries the Windows Event Log for recent update installation
 // failures matching the given title and returns any specific error code found in
 // the event message and true, or empty string and false if not found or on error.
@@ -195,54 +302,35 @@ func fetchDetailedUpdateError(ctx context.Context, title string) (string, bool)
 	return code, true
 }
 
-func installCollection(s *session.UpdateSession, c *updatecollection.Collection, ipu bool) (*installRsp, error) {
-	inst, err := install.NewInstaller(s, c)
-	if err != nil {
-		return nil, fmt.Errorf("error creating installer: \n %v", err)
-	}
-	defer inst.Close()
-
-	if err := inst.Install(); err != nil {
-		return nil, fmt.Errorf("error installing updates:\n %v", err)
-	}
-
-	rc, err := inst.ResultCode()
-	if err != nil {
-		return nil, fmt.Errorf("error getting install ResultCode:\n %v", err)
-	}
-
-	hr, err := inst.HResult()
-	if err != nil {
-		return nil, fmt.Errorf("error getting install ReturnCode:\n %v", err)
-	}
-
-	rb, err := inst.RebootRequired()
-	if err != nil {
-		return nil, fmt.Errorf("error getting install RebootRequired:\n %v", err)
+// blackout returns the blackout period that holds the install at t, if any.
+// Virus definitions are not held.
+func (i *installCmd) blackout(t time.Time) (blackout.Period, bool) {
+	if i.virusDef || i.overrideBlackout {
+		return blackout.Period{}, false
 	}
-
-	if ipu {
-		if err := inst.Commit(); err != nil {
-			return nil, fmt.Errorf("error committing updates:\n %v", err)
-		}
-	}
-
-	return &installRsp{
-		hResult:        hr,
-		resultCode:     rc,
-		rebootRequired: rb,
-	}, err
+	return activeBlackout(t)
 }
 
 func (i *installCmd) installUpdates(ctx context.Context) error {
 	// If monthly patches are disabled, and no specific update type was requested, do nothing.
-	if config.InstallMonthlyPatches == 0 && !i.all && !i.drivers && !i.virusDef && i.kbs == "" {
+	if config.InstallMonthlyPatches == 0 && !i.all && !i.drivers && !i.virusDef && i.kbs == "" && i.selector.Empty() {
 		deck.InfoA("InstallMonthlyPatches is disabled, skipping default update installation.").With(eventID(cablib.EvtMisc)).Go()
 		return nil
 	}
+	if p, ok := i.blackout(time.Now()); ok {
+		deck.InfofA("Blackout period %s is in effect, skipping update installation.", p).With(eventID(cablib.EvtBlackout)).Go()
+		return nil
+	}
+	// Start Windows update session
+	a, err := newAgent()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
 	// Check for reboot status when not installing virus definitions.
 	if !(i.virusDef) {
-		rebootRequired, err := cablib.RebootRequired()
+		rebootRequired, err := a.RebootRequired()
 		if err != nil {
 			return fmt.Errorf("failed to determine reboot status: %v", err)
 		}
@@ -270,123 +358,32 @@ func (i *installCmd) installUpdates(ctx context.Context) error {
 		}
 	}
 
-	// Start Windows update session
-	s, err := session.New()
-	if err != nil {
-		return fmt.Errorf("failed to create new Windows Update session: %v", err)
-	}
-	defer s.Close()
-
-	criteria, rc := i.criteria()
-
-	q, err := search.NewSearcher(s, criteria, config.WSUSServers, config.EnableThirdParty)
-	if err != nil {
-		return fmt.Errorf("failed to create a new searcher object: %v", err)
-	}
-	defer q.Close()
-
-	uc, err := q.QueryUpdates()
-	if er := searchHResult.Set(q.SearchHResult); er != nil {
-		deck.ErrorfA("Error posting metric:\n%v", er).With(eventID(cablib.EvtErrMetricReport)).Go()
-	}
+	ds, rc, err := i.decisions(a)
 	if err != nil {
-		return fmt.Errorf("error encountered when attempting to query for updates: %v", err)
+		return err
 	}
-	defer uc.Close()
-
-	if len(uc.Updates) == 0 {
+	if len(ds) == 0 {
 		deck.InfoA("No updates found to install.").With(eventID(cablib.EvtNoUpdates)).Go()
 		return nil
 	}
-	deck.InfofA("Updates Found:\n%s", strings.Join(uc.Titles(), "\n\n")).With(eventID(cablib.EvtUpdatesFound)).Go()
 
 	installMsgPopped := i.virusDef
 	installingMinOneUpdate := false
 
-	kbs := NewKBSet(i.kbs)
-	if err := initDriverExclusion(); err != nil {
-		deck.ErrorfA("Error initializing driver exclusions:\n%v", err).With(eventID(cablib.EvtErrDriverExclusion)).Go()
-	}
-	excludes := excludedDrivers.get()
-outerLoop:
-	for _, u := range uc.Updates {
-		for _, e := range excludes {
-			t := time.Time{}
-			if e.DriverDateVer != "" {
-				t, err = time.Parse("2006-01-02", e.DriverDateVer)
-				if err != nil {
-					deck.WarningfA("Failed to parse driver date version provided in exclusion json: %v", err).With(eventID(cablib.EvtErrDriverExclusion)).Go()
-				}
-			}
-			// Check if at least one driver exclusion exists and matches the update being evaluated.
-			driverFilterExists := e.DriverClass != "" || !t.IsZero()
-			driverClassMatch := e.DriverClass == "" || e.DriverClass == u.DriverClass
-			driverVersionMatch := t.IsZero() || t.Equal(u.DriverVerDate)
-			if driverFilterExists && driverClassMatch && driverVersionMatch {
-				deck.InfofA(
-					"Driver update %q excluded.\nFiltered driver class: %q\nFiltered driver date version: %q",
-					u.Title, e.DriverClass, e.DriverDateVer,
-				).With(eventID(cablib.EvtDriverUpdateExcluded)).Go()
-				continue outerLoop
-			}
-		}
-		if !(u.InCategories(rc)) {
-			deck.InfofA("Skipping update %s.\nRequiredClassifications:\n%v\nUpdate classifications:\n%v",
-				u.Title,
-				rc,
-				u.Categories).With(eventID(cablib.EvtUpdateSkip)).Go()
+	for _, d := range ds {
+		i.logDecision(d, rc)
+		if d.Action != policy.Install {
 			continue
 		}
+		u := d.Update
 
 		if !(u.EulaAccepted) {
 			deck.InfofA("Accepting EULA for update: %s", u.Title).With(eventID(cablib.EvtMisc)).Go()
-			if err := u.AcceptEula(); err != nil {
+			if err := a.AcceptEula(u); err != nil {
 				deck.ErrorfA("Failed to accept EULA for update %s:\n%s", u.Title, err).With(eventID(cablib.EvtErrMisc)).Go()
 			}
 		}
 
-		if kbs.Size() > 0 {
-			if !kbs.Search(u.KBArticleIDs) {
-				deck.InfofA("Skipping update %s.\nRequired KBs:\n%s\nUpdate KBs:\n%v",
-					u.Title,
-					kbs,
-					u.KBArticleIDs).With(eventID(cablib.EvtUpdateSkip)).Go()
-				continue
-			}
-		}
-		if i.deadlineOnly {
-			deadline := time.Duration(config.Deadline) * 24 * time.Hour
-			pastDeadline := time.Now().After(u.LastDeploymentChangeTime.Add(deadline))
-			if u.DriverClass != "" {
-				deck.InfofA(
-					"Skipping driver %s with class %s and date version %s.\nDrivers are only installed during a maintenance window at this time.",
-					u.Title,
-					u.DriverClass,
-					u.DriverVerDate).With(eventID(cablib.EvtUpdateSkip)).Go()
-				continue
-			}
-			if !pastDeadline {
-				deck.InfofA(
-					"Skipping update %s.\nUpdate deployed on %v has not reached the %d day threshold.",
-					u.Title,
-					u.LastDeploymentChangeTime,
-					config.Deadline).With(eventID(cablib.EvtUpdateSkip)).Go()
-				continue
-			}
-			deck.InfofA(
-				"Update %s deployed on %v has exceeded the %d day threshold.",
-				u.Title,
-				u.LastDeploymentChangeTime,
-				config.Deadline).With(eventID(cablib.EvtUpdatesFound)).Go()
-		}
-
-		c, err := updatecollection.New()
-		if err != nil {
-			deck.ErrorfA("Failed to create collection: %v", err).With(eventID(cablib.EvtErrMisc)).Go()
-			continue
-		}
-		c.Add(u.Item)
-
 		if !installMsgPopped && !u.InCategories([]string{"Definition Updates"}) {
 			installingMessage()
 			installMsgPopped = true
@@ -404,18 +401,16 @@ outerLoop:
 
 		deck.InfofA("Downloading Update:\n%v", u).With(eventID(cablib.EvtDownload)).Go()
 
-		rc, err := downloadCollection(s, c)
+		rc, err := a.Download(u)
 		if err != nil {
 			deck.ErrorA(err).With(eventID(cablib.EvtErrMisc)).Go()
-			c.Close()
 			continue
 		}
-		if rc == 2 {
+		if rc == agent.Succeeded {
 			deck.InfofA("Successfully downloaded update:\n %s", u.Title).With(eventID(cablib.EvtDownload)).Go()
 		} else {
 
 			deck.ErrorfA("Failed to download update:\n %s\n ReturnCode: %d", u.Title, rc).With(eventID(cablib.EvtErrDownloadFailure)).Go()
-			c.Close()
 			continue
 		}
 
@@ -426,41 +421,37 @@ outerLoop:
 			ipu = true
 		}
 
-		rsp, err := installCollection(s, c, ipu)
+		rsp, err := a.Install(u, ipu)
 		if err != nil {
 			deck.ErrorA(err).With(eventID(cablib.EvtErrMisc)).Go()
-			c.Close()
 			continue
 		}
 
-		if err := installHResult.Set(rsp.hResult); err != nil {
+		if err := installHResult.Set(rsp.HResult); err != nil {
 			deck.ErrorfA("Error posting metric:\n%v", err).With(eventID(cablib.EvtErrMetricReport)).Go()
 		}
-		if rsp.resultCode == 2 {
-			deck.InfofA("Successfully installed update:\n%s\nHResult Code: %s", u.Title, rsp.hResult).With(eventID(cablib.EvtInstall)).Go()
+		if rsp.ResultCode == agent.Succeeded {
+			deck.InfofA("Successfully installed update:\n%s\nHResult Code: %s", u.Title, rsp.HResult).With(eventID(cablib.EvtInstall)).Go()
 		} else {
-			deck.ErrorfA("Failed to install update:\n%s\nReturnCode: %d\nHResult Code: %s", u.Title, rsp.resultCode, rsp.hResult).With(eventID(cablib.EvtErrInstallFailure)).Go()
+			deck.ErrorfA("Failed to install update:\n%s\nReturnCode: %d\nHResult Code: %s", u.Title, rsp.ResultCode, rsp.HResult).With(eventID(cablib.EvtErrInstallFailure)).Go()
 			if code, ok := fetchDetailedUpdateError(ctx, u.Title); ok {
 				deck.WarningfA("Detailed error for update %q from Windows Update Client log: %s", u.Title, code).Go()
 			}
-			c.Close()
 			continue
 		}
 
-		deck.InfofA("Install of KB %s; Reboot Required: %t", u.KBArticleIDs, rsp.rebootRequired).With(eventID(cablib.EvtRebootRequired)).Go()
+		deck.InfofA("Install of KB %s; Reboot Required: %t", u.KBArticleIDs, rsp.RebootRequired).With(eventID(cablib.EvtRebootRequired)).Go()
 
-		if rsp.rebootRequired && !u.InCategories([]string{"Definition Updates"}) {
+		if rsp.RebootRequired && !u.InCategories([]string{"Definition Updates"}) {
 			deck.InfofA("Adding KB %s to reboot list.", u.KBArticleIDs).With(eventID(cablib.EvtRebootRequired)).Go()
 			rebootList = append(rebootList, u.KBArticleIDs...)
 		}
 
-		if rsp.rebootRequired && u.InCategories([]string{"Upgrades"}) {
+		if rsp.RebootRequired && u.InCategories([]string{"Upgrades"}) {
 			if err := cablib.SetInstallAtShutdown(); err != nil {
 				deck.ErrorfA("Failed to set `InstallAtShutdown` registry value: %v", err).With(eventID(cablib.EvtErrPowerMgmt)).Go()
 			}
 		}
-
-		c.Close()
 	}
 
 	if installingMinOneUpdate {
@@ -476,37 +467,53 @@ outerLoop:
 	}
 
 	if len(rebootList) > 0 {
-		if err := cablib.AddRebootUpdates(rebootList); err != nil {
-			deck.ErrorfA("Failed to write updates requiring reboot to registry: %v", err).With(eventID(cablib.EvtRebootRequired)).Go()
-		}
+		scheduleReboot(rebootList, i.overdue, i.overrideBlackout)
+	}
 
-		// Use active hours if enabled and available, otherwise use the standard reboot delay.
-		now := time.Now()
-		timerEnd := now.Add(time.Second * time.Duration(config.RebootDelay))
-		rebootTime := timerEnd
-		if config.ActiveHoursEnabled == 1 {
-			ah, err := client.Label(int(config.AukeraPort), `active_hours`)
-			if err != nil {
-				deck.ErrorfA("Error getting maintenance window %q with error:\n%v", `active_hours`, err).With(eventID(cablib.EvtErrMaintWindow)).Go()
-			}
-			if len(ah) != 0 {
-				todayEnd := ah[0].Closes
-				tomorrowEnd := todayEnd.Add(time.Hour * time.Duration(24))
-				// If the active hours end time is in the future, use the end time.
-				// Otherwise, use the same end time of the next day.
-				if todayEnd.After(now) {
-					rebootTime = todayEnd
-				} else {
-					rebootTime = tomorrowEnd
-				}
-			}
+	return nil
+}
+
+// scheduleReboot records the KBs requiring a reboot and sets the reboot time,
+// using the end of active hours if enabled and available, otherwise the
+// standard reboot delay. Updates installed past their enforcement deadline
+// reboot after the shorter DeadlineRebootDelay instead. A reboot time within
+// a blackout period is moved to its end, unless overrideBlackout is set.
+func scheduleReboot(kbs []string, overdue, overrideBlackout bool) {
+	if err := cablib.AddRebootUpdates(kbs); err != nil {
+		deck.ErrorfA("Failed to write updates requiring reboot to registry: %v", err).With(eventID(cablib.EvtRebootRequired)).Go()
+	}
+
+	now := time.Now()
+	timerEnd := now.Add(time.Second * time.Duration(config.RebootDelay))
+	rebootTime := timerEnd
+	if overdue {
+		rebootTime = now.Add(time.Second * time.Duration(config.DeadlineRebootDelay))
+	} else if config.ActiveHoursEnabled == 1 {
+		ah, err := client.Label(int(config.AukeraPort), `active_hours`)
+		if err != nil {
+			deck.ErrorfA("Error getting maintenance window %q with error:\n%v", `active_hours`, err).With(eventID(cablib.EvtErrMaintWindow)).Go()
 		}
-		rebootMessage(rebootTime)
-		if err := cablib.SetRebootTime(rebootTime); err != nil {
-			deck.ErrorfA("Failed to set reboot time:\n%v", err).With(eventID(cablib.EvtErrPowerMgmt)).Go()
+		if len(ah) != 0 {
+			todayEnd := ah[0].Closes
+			tomorrowEnd := todayEnd.Add(time.Hour * time.Duration(24))
+			// If the active hours end time is in the future, use the end time.
+			// Otherwise, use the same end time of the next day.
+			if todayEnd.After(now) {
+				rebootTime = todayEnd
+			} else {
+				rebootTime = tomorrowEnd
+			}
 		}
-		rebootEvent <- true
 	}
-
-	return nil
+	if overrideBlackout {
+		rebootOverride.set(rebootTime)
+	} else if until := blackoutCalendar().Until(rebootTime); !until.IsZero() {
+		deck.InfofA("Reboot time %s falls in a blackout period, moving it to %s.", rebootTime, until).With(eventID(cablib.EvtBlackout)).Go()
+		rebootTime = until
+	}
+	rebootMessage(rebootTime)
+	if err := cablib.SetRebootTime(rebootTime); err != nil {
+		deck.ErrorfA("Failed to set reboot time:\n%v", err).With(eventID(cablib.EvtErrPowerMgmt)).Go()
+	}
+	rebootEvent <- true
 }
@@ -14,10 +14,15 @@
 package main
 
 import (
+	"golang.org/x/net/context"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/blackout"
 	"github.com/google/cabbie/search"
+	"github.com/google/cabbie/updates"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -89,3 +94,54 @@ func TestGetCriteria(t *testing.T) {
 		}
 	}
 }
+
+func TestInstallUpdatesFake(t *testing.T) {
+	config = newFakeConfig()
+	f := &agent.Fake{Updates: []*updates.Update{
+		{Title: "Wanted", Identity: updates.Identity{UpdateID: "wanted"}, KBArticleIDs: []string{"1234567"}, EulaAccepted: true},
+		{Title: "Unwanted", Identity: updates.Identity{UpdateID: "unwanted"}, KBArticleIDs: []string{"7654321"}, EulaAccepted: true},
+		{Title: "Installed", Identity: updates.Identity{UpdateID: "installed"}, KBArticleIDs: []string{"1234567"}, IsInstalled: true},
+	}}
+	defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+	newAgent = func() (agent.Agent, error) { return f, nil }
+
+	i := installCmd{kbs: "KB1234567"}
+	if err := i.installUpdates(context.Background()); err != nil {
+		t.Fatalf("installUpdates() returned unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"wanted"}, f.Installed); diff != "" {
+		t.Errorf("installUpdates() installed unexpected updates (-want +got):\n%s", diff)
+	}
+	if !f.Closed {
+		t.Errorf("installUpdates() did not close the update session")
+	}
+}
+
+func TestInstallUpdatesBlackout(t *testing.T) {
+	config = newFakeConfig()
+	now := time.Now()
+	defer func(f func() (blackout.Calendar, []blackout.FileError, error)) { loadBlackout = f }(loadBlackout)
+	loadBlackout = func() (blackout.Calendar, []blackout.FileError, error) {
+		return blackout.Calendar{{Name: "Freeze", Start: now.Add(-time.Hour), End: now.Add(time.Hour)}}, nil, nil
+	}
+	for _, tt := range []struct {
+		i    installCmd
+		want []string
+	}{
+		{installCmd{kbs: "KB1234567"}, nil},
+		{installCmd{kbs: "KB1234567", overrideBlackout: true}, []string{"wanted"}},
+	} {
+		f := &agent.Fake{Updates: []*updates.Update{
+			{Title: "Wanted", Identity: updates.Identity{UpdateID: "wanted"}, KBArticleIDs: []string{"1234567"}, EulaAccepted: true},
+		}}
+		defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+		newAgent = func() (agent.Agent, error) { return f, nil }
+
+		if err := tt.i.installUpdates(context.Background()); err != nil {
+			t.Fatalf("installUpdates(%+v) returned unexpected error: %v", tt.i, err)
+		}
+		if diff := cmp.Diff(tt.want, f.Installed); diff != "" {
+			t.Errorf("installUpdates(%+v) installed unexpected updates (-want +got):\n%s", tt.i, diff)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jobs schedules the periodic jobs of the Cabbie service from when
+// they last ran, so that restarting the service does not postpone them.
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// Job names a periodic job of the service.
+type Job string
+
+const (
+	// Install installs the selected system updates.
+	Install Job = "Install"
+	// List searches for available updates.
+	List Job = "List"
+	// Virus installs virus definition updates.
+	Virus Job = "Virus"
+	// Driver installs driver updates.
+	Driver Job = "Driver"
+	// Enforcement applies the enforcement files.
+	Enforcement Job = "Enforcement"
+	// Remote fetches the remote enforcement document.
+	Remote Job = "Remote"
+)
+
+// Jobs lists every job, in the order they are reported.
+var Jobs = []Job{Install, List, Virus, Driver, Enforcement, Remote}
+
+// Record is when a job last ran, and last ran successfully. Zero times mean
+// never.
+type Record struct {
+	LastRun     time.Time
+	LastSuccess time.Time
+}
+
+// Store persists job records.
+type Store interface {
+	// Load returns the record of j, or the zero Record if it never ran.
+	Load(j Job) (Record, error)
+	// Save replaces the record of j.
+	Save(j Job, r Record) error
+}
+
+// Next returns when a job due every interval that last ran as recorded in r
+// runs next, as of now. Jobs that are overdue, or never ran, run after delay,
+// giving the service time to settle after starting.
+func Next(r Record, interval, delay time.Duration, now time.Time) time.Time {
+	soonest := now.Add(delay)
+	if r.LastRun.IsZero() || r.LastRun.After(now) {
+		// A run in the future means the clock moved back.
+		return soonest
+	}
+	if n := r.LastRun.Add(interval); n.After(soonest) {
+		return n
+	}
+	return soonest
+}
+
+// Timer fires when a job is due. Like a time.Ticker, it delivers on C, but
+// the first run is scheduled from the recorded last run, and each following
+// run from the time passed to Done. Each run is delayed by the spread of the
+// timer.
+type Timer struct {
+	// C delivers the times the job is due.
+	C <-chan time.Time
+
+	job      Job
+	interval time.Duration
+	spread   Spread
+	store    Store
+	timer    *time.Timer
+
+	mu      sync.Mutex
+	record  Record
+	due     time.Time
+	offset  time.Duration
+	stopped bool
+}
+
+// NewTimer returns a timer for j, due every interval. The spread is capped to
+// the interval. The returned timer is usable even if the record of j cannot
+// be loaded, in which case j is treated as never having run and the error is
+// returned as well.
+func NewTimer(s Store, j Job, interval, delay time.Duration, spread Spread, now time.Time) (*Timer, error) {
+	r, err := s.Load(j)
+	if err != nil {
+		r = Record{}
+	}
+	t := &Timer{job: j, interval: interval, spread: spread.Cap(interval), store: s, record: r}
+	t.offset = t.spread.Delay()
+	t.due = Next(r, interval, delay, now).Add(t.offset)
+	t.timer = time.NewTimer(t.due.Sub(now))
+	t.C = t.timer.C
+	return t, err
+}
+
+// Job returns the job of the timer.
+func (t *Timer) Job() Job {
+	return t.job
+}
+
+// Interval returns the time between runs of the job, before spread.
+func (t *Timer) Interval() time.Duration {
+	return t.interval
+}
+
+// Spread returns the spread applied to the runs of the job.
+func (t *Timer) Spread() Spread {
+	return t.spread
+}
+
+// Due returns when the job runs next, or the zero time if the timer is
+// stopped.
+func (t *Timer) Due() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return time.Time{}
+	}
+	return t.due
+}
+
+// Record returns the last recorded run of the job.
+func (t *Timer) Record() Record {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.record
+}
+
+// Done records a run of the job that started at start, and schedules the next
+// one interval later unless the timer is stopped. The spread of the run is
+// not carried over, so that it does not add up over runs.
+func (t *Timer) Done(start time.Time, success bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record.LastRun = start
+	if success {
+		t.record.LastSuccess = start
+	}
+	if !t.stopped {
+		base := start.Add(-t.offset)
+		t.offset = t.spread.Delay()
+		t.due = base.Add(t.interval + t.offset)
+		t.timer.Reset(time.Until(t.due))
+	}
+	return t.store.Save(t.job, t.record)
+}
+
+// Stop turns off the timer. Recorded runs are kept.
+func (t *Timer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	t.timer.Stop()
+}
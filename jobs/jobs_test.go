@@ -0,0 +1,171 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// memory is a Store that keeps records in memory.
+type memory struct {
+	records map[Job]Record
+	err     error
+}
+
+func (m *memory) Load(j Job) (Record, error) {
+	return m.records[j], m.err
+}
+
+func (m *memory) Save(j Job, r Record) error {
+	if m.records == nil {
+		m.records = make(map[Job]Record)
+	}
+	m.records[j] = r
+	return m.err
+}
+
+func TestNext(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc string
+		last time.Time
+		want time.Time
+	}{
+		{"never ran", time.Time{}, now.Add(5 * time.Minute)},
+		{"ran recently", now.Add(-time.Hour), now.Add(71 * time.Hour)},
+		{"overdue", now.Add(-30 * 24 * time.Hour), now.Add(5 * time.Minute)},
+		{"due within the delay", now.Add(-72*time.Hour + time.Minute), now.Add(5 * time.Minute)},
+		{"clock moved back", now.Add(time.Hour), now.Add(5 * time.Minute)},
+	}
+	for _, tt := range tests {
+		got := Next(Record{LastRun: tt.last}, 72*time.Hour, 5*time.Minute, now)
+		if !got.Equal(tt.want) {
+			t.Errorf("Next(%v) = %v, want %v (%s)", tt.last, got, tt.want, tt.desc)
+		}
+	}
+}
+
+func TestTimer(t *testing.T) {
+	now := time.Now()
+	m := &memory{records: map[Job]Record{Driver: {LastRun: now.Add(-100 * time.Hour)}}}
+	timer, err := NewTimer(m, Driver, 72*time.Hour, 10*time.Millisecond, Spread{}, now)
+	if err != nil {
+		t.Fatalf("NewTimer() returned unexpected error: %v", err)
+	}
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("overdue job did not fire after the startup delay")
+	}
+
+	start := time.Now()
+	if err := timer.Done(start, false); err != nil {
+		t.Fatalf("Done() returned unexpected error: %v", err)
+	}
+	want := Record{LastRun: start}
+	if diff := cmp.Diff(want, m.records[Driver]); diff != "" {
+		t.Errorf("Done(%v, false) saved unexpected diff (-want +got):\n%s", start, diff)
+	}
+	if err := timer.Done(start, true); err != nil {
+		t.Fatalf("Done() returned unexpected error: %v", err)
+	}
+	want = Record{LastRun: start, LastSuccess: start}
+	if diff := cmp.Diff(want, timer.Record()); diff != "" {
+		t.Errorf("Done(%v, true) recorded unexpected diff (-want +got):\n%s", start, diff)
+	}
+	select {
+	case <-timer.C:
+		t.Error("job fired again before its interval")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTimerLoadError(t *testing.T) {
+	m := &memory{err: errors.New("unreadable")}
+	timer, err := NewTimer(m, List, time.Hour, 10*time.Millisecond, Spread{}, time.Now())
+	if err == nil {
+		t.Error("NewTimer() with an unreadable store returned nil error")
+	}
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job with an unreadable record did not fire after the startup delay")
+	}
+}
+
+func TestTimerSpread(t *testing.T) {
+	now := time.Now()
+	last := now.Add(-time.Hour)
+	m := &memory{records: map[Job]Record{List: {LastRun: last}}}
+	spread := Spread{Splay: 10 * time.Minute, Jitter: 5 * time.Minute}
+	timer, err := NewTimer(m, List, 2*time.Hour, time.Minute, spread, now)
+	if err != nil {
+		t.Fatalf("NewTimer() returned unexpected error: %v", err)
+	}
+	defer timer.Stop()
+	earliest, latest := last.Add(2*time.Hour+10*time.Minute), last.Add(2*time.Hour+15*time.Minute)
+	if due := timer.Due(); due.Before(earliest) || !due.Before(latest) {
+		t.Errorf("Due() = %v, want between %v and %v", due, earliest, latest)
+	}
+	timer.Stop()
+	if due := timer.Due(); !due.IsZero() {
+		t.Errorf("Due() after Stop() = %v, want zero", due)
+	}
+}
+
+func TestSpreadCap(t *testing.T) {
+	tests := []struct {
+		in   Spread
+		max  time.Duration
+		want Spread
+	}{
+		{Spread{Splay: time.Minute, Jitter: time.Minute}, time.Hour, Spread{Splay: time.Minute, Jitter: time.Minute}},
+		{Spread{Splay: time.Minute, Jitter: time.Hour}, time.Hour, Spread{Splay: time.Minute, Jitter: 59 * time.Minute}},
+		{Spread{Splay: 2 * time.Hour}, time.Hour, Spread{Splay: time.Hour - 1, Jitter: 0}},
+		{Spread{Splay: time.Minute}, 0, Spread{}},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Cap(tt.max); got != tt.want {
+			t.Errorf("%v.Cap(%v) = %v, want %v", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestHostSplay(t *testing.T) {
+	max := 30 * time.Minute
+	a := HostSplay("host-a", List, max)
+	if a < 0 || a >= max {
+		t.Errorf("HostSplay(host-a) = %v, want below %v", a, max)
+	}
+	if b := HostSplay("HOST-A", List, max); b != a {
+		t.Errorf("HostSplay(HOST-A) = %v, want %v as for host-a", b, a)
+	}
+	spread := map[time.Duration]bool{}
+	for _, h := range []string{"host-a", "host-b", "host-c", "host-d", "host-e"} {
+		spread[HostSplay(h, List, max)] = true
+	}
+	if len(spread) < 2 {
+		t.Errorf("HostSplay() returned the same splay for 5 hosts")
+	}
+	if got := HostSplay("host-a", List, 0); got != 0 {
+		t.Errorf("HostSplay() with no maximum = %v, want 0", got)
+	}
+}
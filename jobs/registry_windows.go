@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/cabbie/cablib"
+	"golang.org/x/sys/windows/registry"
+)
+
+// regPath is the registry key job records are kept under.
+var regPath = cablib.RegPath + `Jobs`
+
+// Registry stores job records in the registry, as binary time values named
+// after the job, such as DriverLastRun and DriverLastSuccess.
+type Registry struct{}
+
+func loadTime(k registry.Key, name string) (time.Time, error) {
+	var t time.Time
+	b, _, err := k.GetBinaryValue(name)
+	if err == registry.ErrNotExist {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	err = t.UnmarshalBinary(b)
+	return t, err
+}
+
+// Load returns the record of j.
+func (Registry) Load(j Job) (Record, error) {
+	var r Record
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, regPath, registry.QUERY_VALUE)
+	if err == registry.ErrNotExist {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	defer k.Close()
+
+	if r.LastRun, err = loadTime(k, string(j)+"LastRun"); err != nil {
+		return r, err
+	}
+	r.LastSuccess, err = loadTime(k, string(j)+"LastSuccess")
+	return r, err
+}
+
+// Save replaces the record of j.
+func (Registry) Save(j Job, r Record) error {
+	k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, regPath, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	for name, t := range map[string]time.Time{"LastRun": r.LastRun, "LastSuccess": r.LastSuccess} {
+		if t.IsZero() {
+			continue
+		}
+		b, err := t.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if err := k.SetBinaryValue(string(j)+name, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
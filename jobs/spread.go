@@ -0,0 +1,71 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jobs
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Spread delays the runs of a job, so that hosts on the same schedule do not
+// all search and download updates at once.
+type Spread struct {
+	// Splay is added to every run. It differs between hosts, see HostSplay.
+	Splay time.Duration
+	// Jitter is the upper bound of a random delay drawn for each run.
+	Jitter time.Duration
+}
+
+func (s Spread) String() string {
+	return fmt.Sprintf("splay %v, jitter up to %v", s.Splay, s.Jitter)
+}
+
+// Delay returns the delay of a run: the splay and a new random jitter.
+func (s Spread) Delay() time.Duration {
+	d := s.Splay
+	if s.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(s.Jitter)))
+	}
+	return d
+}
+
+// Cap limits the spread to at most max in total, keeping the splay first.
+func (s Spread) Cap(max time.Duration) Spread {
+	if max <= 0 {
+		return Spread{}
+	}
+	if s.Splay >= max {
+		s.Splay = max - 1
+	}
+	if s.Splay+s.Jitter > max {
+		s.Jitter = max - s.Splay
+	}
+	return s
+}
+
+// HostSplay returns a delay below max that is derived from the host name and
+// job, so that it is the same across restarts of a host but spread across
+// hosts.
+func HostSplay(host string, j Job, max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(host) + "/" + string(j)))
+	return time.Duration(h.Sum64() % uint64(max))
+}
@@ -16,15 +16,18 @@ package main
 import (
 	"golang.org/x/net/context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"flag"
 	"github.com/google/cabbie/cablib"
+	"github.com/google/cabbie/policy"
 	"github.com/google/cabbie/search"
-	"github.com/google/cabbie/session"
+	"github.com/google/cabbie/updates"
 	"github.com/google/deck"
 	"github.com/google/subcommands"
 )
@@ -33,37 +36,100 @@ import (
 type listCmd struct {
 	hidden bool
 	ids    bool
+	format string
+}
+
+// listRecord is the machine-readable form of an available update.
+type listRecord struct {
+	Required bool
+	*updates.Update
 }
 
 func (listCmd) Name() string     { return "list" }
 func (listCmd) Synopsis() string { return "list updates available for install." }
 func (listCmd) Usage() string {
-	return fmt.Sprintf("%s list [--hidden] [--ids]\n", filepath.Base(os.Args[0]))
+	return fmt.Sprintf("%s list [--hidden] [--ids] [--format=text|json|csv|table]\n", filepath.Base(os.Args[0]))
 
 }
 func (c *listCmd) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.hidden, "hidden", false, "show updates that have been marked as hidden.")
 	f.BoolVar(&c.ids, "ids", false, "show UpdateIDs alongside each update.")
+	f.StringVar(&c.format, "format", formatText, "output format, one of text, json, csv or table.")
 }
 
 func (c listCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	if err := vetFormat(c.format, formatText, formatJSON, formatCSV, formatTable); err != nil {
+		fmt.Printf("%v\n%s\nUsage: %s\n", err, c.Synopsis(), c.Usage())
+		return subcommands.ExitUsageError
+	}
 	rc := subcommands.ExitSuccess
-	var requiredUpdates, optionalUpdates []string
-	var err error
-	requiredUpdates, optionalUpdates, err = listUpdates(c.hidden, c.ids)
+	required, optional, err := listUpdates(c.hidden)
 	if err != nil {
 		fmt.Printf("failed to get updates with error:\n%v\n", err)
 		rc = subcommands.ExitFailure
 	}
+	requiredUpdates, optionalUpdates := updateLabels(required, c.ids), updateLabels(optional, c.ids)
 	msg := fmt.Sprintf("Found %d required updates.\nRequired updates:\n%s\nOptional updates:\n%s\n",
 		len(requiredUpdates), strings.Join(requiredUpdates, "\n"), strings.Join(optionalUpdates, "\n"))
 	deck.InfoA(msg).With(eventID(cablib.EvtList)).Go()
-	fmt.Print(msg)
+	if c.format == formatText {
+		fmt.Print(msg)
+		return rc
+	}
+	if err := writeUpdates(os.Stdout, c.format, required, optional); err != nil {
+		fmt.Printf("failed to write updates: %v\n", err)
+		rc = subcommands.ExitFailure
+	}
 	return rc
 }
 
-// listUpdates queries the update server and returns a list of available updates
-func listUpdates(hidden bool, ids bool) ([]string, []string, error) {
+// updateLabels returns the titles of the updates, with their UpdateIDs if ids is set.
+func updateLabels(us []*updates.Update, ids bool) []string {
+	var labels []string
+	for _, u := range us {
+		if ids {
+			labels = append(labels, fmt.Sprintf("%s | %s", u.Title, u.Identity.UpdateID))
+		} else {
+			labels = append(labels, u.Title)
+		}
+	}
+	return labels
+}
+
+func writeUpdates(w io.Writer, format string, required, optional []*updates.Update) error {
+	records := []listRecord{}
+	for _, u := range required {
+		records = append(records, listRecord{Required: true, Update: u})
+	}
+	for _, u := range optional {
+		records = append(records, listRecord{Required: false, Update: u})
+	}
+	header := []string{"required", "title", "kbs", "update_id", "revision", "categories", "msrc_severity", "cve_ids", "max_download_size", "last_deployment_change_time"}
+	var rows [][]string
+	for _, r := range records {
+		var cats []string
+		for _, c := range r.Categories {
+			cats = append(cats, c.Name)
+		}
+		rows = append(rows, []string{
+			strconv.FormatBool(r.Required),
+			r.Title,
+			strings.Join(r.KBArticleIDs, ";"),
+			r.Identity.UpdateID,
+			strconv.Itoa(r.Identity.RevisionNumber),
+			strings.Join(cats, ";"),
+			r.MsrcSeverity,
+			strings.Join(r.CveIDs, ";"),
+			strconv.Itoa(r.MaxDownloadSize),
+			formatTime(r.LastDeploymentChangeTime),
+		})
+	}
+	return writeRecords(w, format, records, header, rows)
+}
+
+// listUpdates queries the update server and returns the available updates,
+// split into those selected for install and the rest.
+func listUpdates(hidden bool) ([]*updates.Update, []*updates.Update, error) {
 	// Set search criteria
 	c := search.BasicSearch + " OR Type='Driver' OR " + search.BasicSearch + " AND Type='Software'"
 	if hidden {
@@ -73,45 +139,33 @@ func listUpdates(hidden bool, ids bool) ([]string, []string, error) {
 	}
 
 	// Start Windows update session
-	s, err := session.New()
+	a, err := newAgent()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create new Windows Update session: %v", err)
+		return nil, nil, err
 	}
-	defer s.Close()
+	defer a.Close()
 
-	q, err := search.NewSearcher(s, c, config.WSUSServers, config.EnableThirdParty)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create a new searcher object: %v", err)
-	}
-	defer q.Close()
-
-	deck.InfofA("Using search criteria: %s\n", q.Criteria).With(eventID(cablib.EvtSearch)).Go()
-	uc, err := q.QueryUpdates()
+	deck.InfofA("Using search criteria: %s\n", c).With(eventID(cablib.EvtSearch)).Go()
+	uc, err := a.Search(c)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error encountered when attempting to query for updates: %v", err)
 	}
-	defer uc.Close()
 
-	var reqUpdates, optUpdates []string
+	var reqUpdates, optUpdates []*updates.Update
 	devicePatched := true
-	for _, u := range uc.Updates {
+	s := policy.Settings{RequiredCategories: config.RequiredCategories, Now: time.Now()}
+	e := excludedDrivers.get()
+	for _, d := range policy.Evaluate(uc, s, e) {
+		u := d.Update
 
-		// Add to optional updates list if the update does not match the required categories.
-		if !u.InCategories(config.RequiredCategories) {
-			if ids {
-				optUpdates = append(optUpdates, fmt.Sprintf("%s | %s", u.Title, u.Identity.UpdateID))
-			} else {
-				optUpdates = append(optUpdates, u.Title)
-			}
+		// Add to optional updates list if the update is not selected for install.
+		if d.Action != policy.Install {
+			optUpdates = append(optUpdates, u)
 			continue
 		}
 		// Skip virus updates as they always exist.
 		if !u.InCategories([]string{"Definition Updates"}) {
-			if ids {
-				reqUpdates = append(reqUpdates, fmt.Sprintf("%s | %s", u.Title, u.Identity.UpdateID))
-			} else {
-				reqUpdates = append(reqUpdates, u.Title)
-			}
+			reqUpdates = append(reqUpdates, u)
 			if (time.Now().Sub(u.LastDeploymentChangeTime).Hours() / 24) > 31 {
 				devicePatched = false
 			}
@@ -0,0 +1,259 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"golang.org/x/net/context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"flag"
+	"github.com/google/cabbie/blackout"
+	"github.com/google/cabbie/cablib"
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/policy"
+	"github.com/google/cabbie/updates"
+	"github.com/google/deck"
+	"github.com/google/subcommands"
+)
+
+// Available flags
+type planCmd struct {
+	install installCmd
+	format  string
+}
+
+// planRecord is the machine-readable form of a policy decision.
+type planRecord struct {
+	Title    string
+	UpdateID string
+	KBs      []string
+	Action   policy.Action
+	Reason   policy.Reason
+	Detail   string
+}
+
+// planOutput is the machine-readable form of a plan.
+type planOutput struct {
+	// Notes describe conditions that would stop the install from running.
+	Notes   []string
+	Updates []planRecord
+}
+
+func (planCmd) Name() string { return "plan" }
+func (planCmd) Synopsis() string {
+	return "Show the updates install and the enforcement files would select, and why, without installing anything."
+}
+func (planCmd) Usage() string {
+	return fmt.Sprintf("%s plan [--drivers | --virus_def | --kbs=\"<KBNumber>\" | --all] [--deadlineOnly] [--format=table|json|csv]\n", filepath.Base(os.Args[0]))
+}
+
+func (c *planCmd) SetFlags(f *flag.FlagSet) {
+	c.install.SetFlags(f)
+	f.StringVar(&c.format, "format", formatTable, "Output format, one of table, json or csv.")
+}
+
+func (c *planCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	if err := vetFlags(c.install); err != nil {
+		return subcommands.ExitUsageError
+	}
+	if err := vetFormat(c.format, formatTable, formatJSON, formatCSV); err != nil {
+		fmt.Printf("%v\n%s\nUsage: %s\n", err, c.Synopsis(), c.Usage())
+		return subcommands.ExitUsageError
+	}
+
+	ds, notes, err := c.plan()
+	if err != nil {
+		fmt.Printf("Failed to plan update installation: %v\n", err)
+		deck.ErrorfA("Failed to plan update installation: %v", err).With(eventID(cablib.EvtErrQueryFailure)).Go()
+		return subcommands.ExitFailure
+	}
+	if err := writePlan(os.Stdout, c.format, ds, notes, time.Now()); err != nil {
+		fmt.Printf("Failed to write plan: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+// plan runs the search and selection of an install without changing the
+// device. Notes describe conditions that would stop the install from running.
+func (c *planCmd) plan() ([]policy.Decision, []string, error) {
+	var notes []string
+	i := c.install
+	if config.InstallMonthlyPatches == 0 && !i.all && !i.drivers && !i.virusDef && i.kbs == "" {
+		notes = append(notes, "InstallMonthlyPatches is disabled; a default install would do nothing.")
+	}
+
+	a, err := newAgent()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer a.Close()
+
+	if !i.virusDef {
+		rbr, err := a.RebootRequired()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to determine reboot status: %v", err)
+		}
+		if rbr {
+			notes = append(notes, "A reboot is pending; install would not run until the device restarts.")
+		}
+	}
+
+	ds, _, err := i.decisions(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if e, err := getEnforcements(); err != nil {
+		notes = append(notes, fmt.Sprintf("The enforcement files could not be read and are not applied: %v", err))
+	} else {
+		applyEnforcement(ds, e, now)
+	}
+	if p, ok := i.blackout(now); ok {
+		notes = append(notes, fmt.Sprintf("Blackout period %s is in effect; installs are held until it ends.", p))
+		holdBlackout(ds, p)
+	}
+	return ds, notes, nil
+}
+
+// holdBlackout defers the installs of ds until the blackout period p ends.
+// The required updates applyEnforcement installs during a blackout override
+// it, and are left alone.
+func holdBlackout(ds []policy.Decision, p blackout.Period) {
+	for k, d := range ds {
+		if d.Action != policy.Install || d.Reason == policy.EnforcementRequired || d.Reason == policy.InstallByReached {
+			continue
+		}
+		ds[k] = policy.Decision{Update: d.Update, Action: policy.Defer, Reason: policy.BlackoutHeld, Deadline: p.End}
+	}
+}
+
+// applyEnforcement changes the decisions of ds for the updates that the
+// enforcements e uninstall, hide, install or defer, as enforce would at now.
+// Uninstalled and hidden updates take precedence over required ones, as they
+// do in enforcement.Get.
+func applyEnforcement(ds []policy.Decision, e enforcement.Enforcements, now time.Time) {
+	us := make([]*updates.Update, len(ds))
+	for k, d := range ds {
+		us[k] = d.Update
+	}
+	done := make([]bool, len(ds))
+	hidden := e.HiddenSelector()
+	for k, u := range us {
+		for _, b := range []struct {
+			key    string
+			values []string
+			reason policy.Reason
+		}{
+			{enforcement.KeyUninstall, e.Uninstall, policy.UninstallByEnforcement},
+			{enforcement.KeyHidden, e.Hidden, policy.HiddenByEnforcement},
+			{enforcement.KeyHiddenUpdateID, e.HiddenUpdateID, policy.HiddenByEnforcement},
+		} {
+			if v, ok := enforcedRule(u, b.values); ok {
+				ds[k] = policy.Decision{Update: u, Action: policy.Exclude, Reason: b.reason, Origins: e.OriginsOf(b.key, v)}
+				done[k] = true
+				break
+			}
+		}
+		if !done[k] && hidden.Match(u) {
+			ds[k] = policy.Decision{Update: u, Action: policy.Exclude, Reason: policy.HiddenByEnforcement}
+			done[k] = true
+		}
+	}
+
+	overdue, required, deferred := enforcementInstalls(e, now)
+	apply := func(i *installCmd, action policy.Action, reason policy.Reason) {
+		if i == nil {
+			return
+		}
+		pe := enforcement.Enforcements{ExcludedDrivers: e.ExcludedDrivers, Origins: e.Origins, HiddenTitleRegex: i.hidden.TitleRegex}
+		for k, d := range policy.Evaluate(us, i.selection(nil), pe) {
+			if done[k] || d.Action != policy.Install {
+				continue
+			}
+			// A deferred update is still installed if install selects it.
+			if action == policy.Defer && ds[k].Action == policy.Install {
+				continue
+			}
+			v, _ := enforcedRule(us[k], e.Required)
+			ds[k] = policy.Decision{Update: us[k], Action: action, Reason: reason, Origins: e.OriginsOf(enforcement.KeyRequired, v), Deadline: e.InstallBy[v]}
+			done[k] = true
+		}
+	}
+	apply(overdue, policy.Install, policy.InstallByReached)
+	apply(required, policy.Install, policy.EnforcementRequired)
+	if len(deferred) > 0 {
+		apply(&installCmd{kbs: strings.Join(deferred, ",")}, policy.Defer, policy.InstallByNotReached)
+	}
+}
+
+// enforcedRule returns the value of values that names u, by KB or UpdateID.
+func enforcedRule(u *updates.Update, values []string) (string, bool) {
+	for _, v := range values {
+		if v == u.Identity.UpdateID || NewKBSetFromSlice([]string{v}).Search(u.KBArticleIDs) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func writePlan(w io.Writer, format string, ds []policy.Decision, notes []string, now time.Time) error {
+	out := planOutput{Notes: notes, Updates: []planRecord{}}
+	if out.Notes == nil {
+		out.Notes = []string{}
+	}
+	// CSV carries the notes in a column of every row, and in a row of their
+	// own when there are no candidate updates.
+	note := strings.Join(notes, " ")
+	var rows [][]string
+	for _, d := range ds {
+		r := planRecord{
+			Title:    d.Update.Title,
+			UpdateID: d.Update.Identity.UpdateID,
+			KBs:      d.Update.KBArticleIDs,
+			Action:   d.Action,
+			Reason:   d.Reason,
+			Detail:   d.Explain(now),
+		}
+		out.Updates = append(out.Updates, r)
+		rows = append(rows, []string{string(r.Action), strings.Join(r.KBs, ";"), r.Title, r.UpdateID, string(r.Reason), r.Detail, note})
+	}
+	header := []string{"action", "kbs", "title", "update_id", "reason", "detail", "notes"}
+
+	switch format {
+	case formatTable:
+		for _, n := range notes {
+			fmt.Fprintln(w, n)
+		}
+		if len(rows) == 0 {
+			_, err := fmt.Fprintln(w, "No candidate updates found.")
+			return err
+		}
+		// The notes are printed above the table.
+		header = header[:len(header)-1]
+		for k := range rows {
+			rows[k] = rows[k][:len(header)]
+		}
+	case formatCSV:
+		if len(rows) == 0 && note != "" {
+			rows = append(rows, []string{"", "", "", "", "", "", note})
+		}
+	}
+	return writeRecords(w, format, out, header, rows)
+}
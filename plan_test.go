@@ -0,0 +1,139 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/blackout"
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/policy"
+	"github.com/google/cabbie/updates"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlan(t *testing.T) {
+	config = newFakeConfig()
+	f := &agent.Fake{Updates: []*updates.Update{
+		{Title: "Wanted", Identity: updates.Identity{UpdateID: "wanted"}, KBArticleIDs: []string{"1234567"}},
+		{Title: "Unwanted", Identity: updates.Identity{UpdateID: "unwanted"}, KBArticleIDs: []string{"7654321"}},
+	}}
+	defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+	newAgent = func() (agent.Agent, error) { return f, nil }
+
+	c := planCmd{install: installCmd{kbs: "KB1234567"}}
+	ds, _, err := c.plan()
+	if err != nil {
+		t.Fatalf("plan() returned unexpected error: %v", err)
+	}
+	var b bytes.Buffer
+	notes := []string{"A reboot is pending; install would not run until the device restarts."}
+	if err := writePlan(&b, "json", ds, notes, time.Now()); err != nil {
+		t.Fatalf("writePlan() returned unexpected error: %v", err)
+	}
+	var got planOutput
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal(%s) returned unexpected error: %v", b.String(), err)
+	}
+	want := planOutput{
+		Notes: notes,
+		Updates: []planRecord{
+			{Title: "Wanted", UpdateID: "wanted", KBs: []string{"1234567"}, Action: policy.Install, Reason: policy.Selected, Detail: "selected for installation"},
+			{Title: "Unwanted", UpdateID: "unwanted", KBs: []string{"7654321"}, Action: policy.Skip, Reason: policy.KBNotRequested, Detail: "not in the requested KBs"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("plan() returned unexpected diff (-want +got):\n%s", diff)
+	}
+	if len(f.Installed) != 0 || len(f.Downloaded) != 0 {
+		t.Errorf("plan() changed the device: downloaded %v, installed %v", f.Downloaded, f.Installed)
+	}
+}
+
+func TestApplyEnforcement(t *testing.T) {
+	config = newFakeConfig()
+	defer func(f func() (blackout.Calendar, []blackout.FileError, error)) { loadBlackout = f }(loadBlackout)
+	loadBlackout = func() (blackout.Calendar, []blackout.FileError, error) { return nil, nil, nil }
+	now := time.Now()
+	us := []*updates.Update{
+		{Title: "Required", Identity: updates.Identity{UpdateID: "required"}, KBArticleIDs: []string{"1111111"}},
+		{Title: "Overdue", Identity: updates.Identity{UpdateID: "overdue"}, KBArticleIDs: []string{"2222222"}},
+		{Title: "Deferred", Identity: updates.Identity{UpdateID: "deferred"}, KBArticleIDs: []string{"3333333"}},
+		{Title: "Hidden", Identity: updates.Identity{UpdateID: "hidden"}, KBArticleIDs: []string{"4444444"}},
+		{Title: "Hidden by UpdateID", Identity: updates.Identity{UpdateID: "hidden-id"}, KBArticleIDs: []string{"5555555"}},
+		{Title: "Uninstalled", Identity: updates.Identity{UpdateID: "uninstalled"}, KBArticleIDs: []string{"7777777"}},
+		{Title: "Other", Identity: updates.Identity{UpdateID: "other"}, KBArticleIDs: []string{"6666666"}},
+	}
+	e := enforcement.Enforcements{
+		Required:       []string{"1111111", "2222222", "3333333"},
+		Hidden:         []string{"4444444"},
+		HiddenUpdateID: []string{"hidden-id"},
+		Uninstall:      []string{"uninstalled"},
+		InstallBy:      map[string]time.Time{"2222222": now.Add(-time.Hour), "3333333": now.Add(time.Hour)},
+	}
+	var ds []policy.Decision
+	for _, u := range us {
+		ds = append(ds, policy.Decision{Update: u, Action: policy.Skip, Reason: policy.KBNotRequested})
+	}
+	applyEnforcement(ds, e, now)
+
+	want := []policy.Decision{
+		{Update: us[0], Action: policy.Install, Reason: policy.EnforcementRequired},
+		{Update: us[1], Action: policy.Install, Reason: policy.InstallByReached, Deadline: e.InstallBy["2222222"]},
+		{Update: us[2], Action: policy.Defer, Reason: policy.InstallByNotReached, Deadline: e.InstallBy["3333333"]},
+		{Update: us[3], Action: policy.Exclude, Reason: policy.HiddenByEnforcement},
+		{Update: us[4], Action: policy.Exclude, Reason: policy.HiddenByEnforcement},
+		{Update: us[5], Action: policy.Exclude, Reason: policy.UninstallByEnforcement},
+		{Update: us[6], Action: policy.Skip, Reason: policy.KBNotRequested},
+	}
+	if diff := cmp.Diff(want, ds); diff != "" {
+		t.Errorf("applyEnforcement(%v) returned unexpected diff (-want +got):\n%s", e, diff)
+	}
+}
+
+func TestWritePlanCSVNotes(t *testing.T) {
+	var b bytes.Buffer
+	if err := writePlan(&b, "csv", nil, []string{"A reboot is pending."}, time.Now()); err != nil {
+		t.Fatalf("writePlan() returned unexpected error: %v", err)
+	}
+	want := "action,kbs,title,update_id,reason,detail,notes\n,,,,,,A reboot is pending.\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writePlan() returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestHoldBlackout(t *testing.T) {
+	now := time.Now()
+	p := blackout.Period{Name: "Freeze", Start: now.Add(-time.Hour), End: now.Add(time.Hour)}
+	us := []*updates.Update{{Title: "Selected"}, {Title: "Emergency"}, {Title: "Skipped"}}
+	ds := []policy.Decision{
+		{Update: us[0], Action: policy.Install, Reason: policy.Selected},
+		{Update: us[1], Action: policy.Install, Reason: policy.EnforcementRequired},
+		{Update: us[2], Action: policy.Skip, Reason: policy.KBNotRequested},
+	}
+	holdBlackout(ds, p)
+
+	want := []policy.Decision{
+		{Update: us[0], Action: policy.Defer, Reason: policy.BlackoutHeld, Deadline: p.End},
+		{Update: us[1], Action: policy.Install, Reason: policy.EnforcementRequired},
+		{Update: us[2], Action: policy.Skip, Reason: policy.KBNotRequested},
+	}
+	if diff := cmp.Diff(want, ds); diff != "" {
+		t.Errorf("holdBlackout(%s) returned unexpected diff (-want +got):\n%s", p, diff)
+	}
+}
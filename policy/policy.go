@@ -0,0 +1,297 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy decides which of the available updates Cabbie installs, and why.
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/schedule"
+	"github.com/google/cabbie/updates"
+)
+
+// Action is what Cabbie does with an update.
+type Action string
+
+const (
+	// Install indicates that the update is installed.
+	Install Action = "install"
+	// Skip indicates that the update was not selected.
+	Skip Action = "skip"
+	// Defer indicates that the update was selected but is not due yet.
+	Defer Action = "defer"
+	// Exclude indicates that an enforcement rule blocks the update.
+	Exclude Action = "exclude"
+)
+
+// Reason is a machine-readable code explaining a decision.
+type Reason string
+
+const (
+	// Selected indicates that the update matched every selection criteria.
+	Selected Reason = "selected"
+	// DeadlineReached indicates that the update deployment is older than the deadline.
+	DeadlineReached Reason = "deadline-reached"
+	// DriverExcluded indicates that the update matched a driver exclusion rule.
+	DriverExcluded Reason = "driver-excluded"
+	// CategoryMismatch indicates that the update is in none of the required categories.
+	CategoryMismatch Reason = "category-mismatch"
+	// KBNotRequested indicates that the update has none of the requested KBs.
+	KBNotRequested Reason = "kb-not-requested"
+	// DriverMaintenanceOnly indicates that drivers are only installed during a maintenance window.
+	DriverMaintenanceOnly Reason = "driver-maintenance-window-only"
+	// DeadlineNotReached indicates that the update deployment is newer than the deadline.
+	DeadlineNotReached Reason = "deadline-not-reached"
+	// NotSelected indicates that the update matches none of the requested KBs
+	// and enforcement selectors.
+	NotSelected Reason = "not-selected"
+	// HiddenBySelector indicates that the update matched an enforcement hidden selector.
+	HiddenBySelector Reason = "hidden-by-selector"
+	// RingDeferred indicates that the update is not available to the deployment ring yet.
+	RingDeferred Reason = "ring-deferred"
+	// PatchTuesdayDeferred indicates that the update is held until a number of days after Patch Tuesday.
+	PatchTuesdayDeferred Reason = "patch-tuesday-deferred"
+	// PreviewRelease indicates that the update is a skipped preview release.
+	PreviewRelease Reason = "preview-release"
+	// EnforcementRequired indicates that an enforcement requires the update.
+	EnforcementRequired Reason = "enforcement-required"
+	// InstallByReached indicates that the enforcement install-by deadline of the update has passed.
+	InstallByReached Reason = "install-by-reached"
+	// InstallByNotReached indicates that a required update is only installed in the maintenance window until its install-by deadline.
+	InstallByNotReached Reason = "install-by-not-reached"
+	// HiddenByEnforcement indicates that an enforcement hides the update.
+	HiddenByEnforcement Reason = "hidden-by-enforcement"
+	// UninstallByEnforcement indicates that an enforcement uninstalls and hides the update.
+	UninstallByEnforcement Reason = "uninstall-by-enforcement"
+	// BlackoutHeld indicates that the update is held until a blackout period ends.
+	BlackoutHeld Reason = "blackout-held"
+)
+
+// KBFilter matches updates by KB article ID.
+type KBFilter interface {
+	// Search returns true if any of the KB article IDs match.
+	Search(ids []string) bool
+	// Size returns the number of KBs in the filter.
+	Size() int
+}
+
+// Settings configure the selection of updates.
+type Settings struct {
+	// RequiredCategories lists the categories an update must be in one of.
+	// An empty list allows every category.
+	RequiredCategories []string
+	// KBs restricts the selection to matching updates, if not nil or empty.
+	KBs KBFilter
+	// Select restricts the selection to matching updates, if not empty. When
+	// both KBs and Select are set, updates matching either are selected.
+	Select enforcement.Selector
+	// DeadlineOnly restricts the selection to updates past their deadline.
+	DeadlineOnly bool
+	// Deadline is the time allowed after an update is available to the ring
+	// before it is due.
+	Deadline time.Duration
+	// Ring defers the updates selected by category. Updates requested by KB
+	// or selector are not deferred.
+	Ring Ring
+	// PatchDelay, if not nil, also defers the updates selected by category
+	// until a number of days after the Patch Tuesday of their release. Drivers
+	// and definition updates are not deferred.
+	PatchDelay *schedule.PatchDelay
+	// SkipPreviews skips the preview releases selected by category.
+	SkipPreviews bool
+	// Now is the time decisions are made at.
+	Now time.Time
+}
+
+// Decision is the action taken for a single update.
+type Decision struct {
+	Update *updates.Update
+	Action Action
+	Reason Reason
+	// Rule is the driver exclusion matched by DriverExcluded decisions.
+	Rule *enforcement.DriverExclude
+	// Origins are the enforcement files that set the rule of the decision,
+	// if known.
+	Origins []enforcement.Origin
+	// Deadline is when the update is due, for deadline-only and install-by
+	// decisions, or available, for RingDeferred, PatchTuesdayDeferred and
+	// BlackoutHeld decisions.
+	Deadline time.Time
+}
+
+// relative describes t relative to now in whole days.
+func relative(t, now time.Time) string {
+	days := int(now.Sub(t).Hours() / 24)
+	switch {
+	case days == 0 && now.After(t):
+		return "earlier today"
+	case days == 0:
+		return "later today"
+	case days == 1:
+		return "1 day ago"
+	case days == -1:
+		return "in 1 day"
+	case days > 0:
+		return fmt.Sprintf("%d days ago", days)
+	}
+	return fmt.Sprintf("in %d days", -days)
+}
+
+// in names the files of origins, as in "in required.json", or is empty if
+// there are none.
+func in(origins []enforcement.Origin) string {
+	var files []string
+	for _, o := range origins {
+		files = append(files, filepath.Base(o.Path))
+	}
+	if len(files) == 0 {
+		return ""
+	}
+	return " in " + strings.Join(files, ", ")
+}
+
+// Explain describes the decision in human-readable form, as of now.
+func (d Decision) Explain(now time.Time) string {
+	switch d.Reason {
+	case Selected:
+		return "selected for installation"
+	case DeadlineReached:
+		return fmt.Sprintf("deadline reached %s", relative(d.Deadline, now))
+	case DriverExcluded:
+		return fmt.Sprintf("excluded by driver rule %s%s", d.Rule, in(d.Origins))
+	case CategoryMismatch:
+		return "not in RequiredCategories"
+	case KBNotRequested:
+		return "not in the requested KBs"
+	case DriverMaintenanceOnly:
+		return "drivers are only installed during a maintenance window"
+	case DeadlineNotReached:
+		return fmt.Sprintf("deadline %s", relative(d.Deadline, now))
+	case NotSelected:
+		return "not in the requested KBs or enforcement selectors"
+	case HiddenBySelector:
+		return "hidden by an enforcement title expression"
+	case RingDeferred:
+		return fmt.Sprintf("deferred by the deployment ring, available %s", relative(d.Deadline, now))
+	case PatchTuesdayDeferred:
+		return fmt.Sprintf("deferred after Patch Tuesday, available %s", relative(d.Deadline, now))
+	case PreviewRelease:
+		return "preview release"
+	case EnforcementRequired:
+		return fmt.Sprintf("required by enforcement%s", in(d.Origins))
+	case InstallByReached:
+		return fmt.Sprintf("required by enforcement%s, install-by deadline reached %s", in(d.Origins), relative(d.Deadline, now))
+	case InstallByNotReached:
+		return fmt.Sprintf("required by enforcement%s, installed in the maintenance window until its install-by deadline %s", in(d.Origins), relative(d.Deadline, now))
+	case HiddenByEnforcement:
+		return fmt.Sprintf("hidden by enforcement%s", in(d.Origins))
+	case UninstallByEnforcement:
+		return fmt.Sprintf("uninstalled by enforcement%s", in(d.Origins))
+	case BlackoutHeld:
+		return fmt.Sprintf("held by a blackout period, available %s", relative(d.Deadline, now))
+	}
+	return string(d.Reason)
+}
+
+func (d Decision) String() string {
+	return d.Explain(time.Now())
+}
+
+// Evaluate returns one decision per update, in the order given.
+func Evaluate(us []*updates.Update, s Settings, e enforcement.Enforcements) []Decision {
+	var ds []Decision
+	hidden := e.HiddenSelector()
+	s.Select.Compile()
+	for _, u := range us {
+		ds = append(ds, decide(u, s, e, hidden))
+	}
+	return ds
+}
+
+// ToInstall returns the updates of the decisions to install.
+func ToInstall(ds []Decision) []*updates.Update {
+	var us []*updates.Update
+	for _, d := range ds {
+		if d.Action == Install {
+			us = append(us, d.Update)
+		}
+	}
+	return us
+}
+
+func decide(u *updates.Update, s Settings, e enforcement.Enforcements, hidden enforcement.Selector) Decision {
+	d := Decision{Update: u, Action: Install, Reason: Selected}
+	for i := range e.ExcludedDrivers {
+		if e.ExcludedDrivers[i].Match(u) {
+			d.Action, d.Reason, d.Rule = Exclude, DriverExcluded, &e.ExcludedDrivers[i]
+			d.Origins = e.OriginsOf(enforcement.KeyExcludedDrivers, e.ExcludedDrivers[i].String())
+			return d
+		}
+	}
+	if hidden.Match(u) {
+		d.Action, d.Reason = Exclude, HiddenBySelector
+		return d
+	}
+	if !u.InCategories(s.RequiredCategories) {
+		d.Action, d.Reason = Skip, CategoryMismatch
+		return d
+	}
+	byKB := s.KBs != nil && s.KBs.Size() > 0
+	switch {
+	case !s.Select.Empty():
+		if !s.Select.Match(u) && !(byKB && s.KBs.Search(u.KBArticleIDs)) {
+			d.Action, d.Reason = Skip, NotSelected
+			return d
+		}
+	case byKB && !s.KBs.Search(u.KBArticleIDs):
+		d.Action, d.Reason = Skip, KBNotRequested
+		return d
+	}
+	available := u.LastDeploymentChangeTime
+	if !byKB && s.Select.Empty() {
+		if s.SkipPreviews && u.IsPreview() {
+			d.Action, d.Reason = Skip, PreviewRelease
+			return d
+		}
+		available = s.Ring.Available(u)
+		reason := RingDeferred
+		if s.PatchDelay != nil && u.DriverClass == "" && !u.InCategories([]string{"Definition Updates"}) {
+			if p := s.PatchDelay.Available(u.LastDeploymentChangeTime); p.After(available) {
+				available, reason = p, PatchTuesdayDeferred
+			}
+		}
+		if s.Now.Before(available) {
+			d.Action, d.Reason, d.Deadline = Defer, reason, available
+			return d
+		}
+	}
+	if s.DeadlineOnly {
+		if u.DriverClass != "" {
+			d.Action, d.Reason = Defer, DriverMaintenanceOnly
+			return d
+		}
+		d.Deadline = available.Add(s.Deadline)
+		if !s.Now.After(d.Deadline) {
+			d.Action, d.Reason = Defer, DeadlineNotReached
+			return d
+		}
+		d.Reason = DeadlineReached
+	}
+	return d
+}
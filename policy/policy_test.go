@@ -0,0 +1,182 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/schedule"
+	"github.com/google/cabbie/updates"
+)
+
+var now = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+// kbs is a minimal KBFilter for tests.
+type kbs []string
+
+func (k kbs) Search(ids []string) bool {
+	for _, a := range k {
+		for _, b := range ids {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (k kbs) Size() int { return len(k) }
+
+func update(kb, category string, deployed time.Time) *updates.Update {
+	return &updates.Update{
+		Title:                    "KB" + kb,
+		KBArticleIDs:             []string{kb},
+		Categories:               []updates.Category{{Name: category}},
+		LastDeploymentChangeTime: deployed,
+	}
+}
+
+func cve(kb, id string) *updates.Update {
+	u := update(kb, "Security Updates", now)
+	u.CveIDs = []string{id}
+	return u
+}
+
+func driver(class string, date time.Time) *updates.Update {
+	return &updates.Update{
+		Title:         class + " driver",
+		Categories:    []updates.Category{{Name: "Drivers"}},
+		DriverClass:   class,
+		DriverVerDate: date,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	old := now.Add(-30 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+	driverDate := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	released := time.Date(2026, 10, 13, 18, 0, 0, 0, time.UTC)
+	week, err := schedule.NewPatchDelay(7, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	twoDays, err := schedule.NewPatchDelay(2, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	preview := update("1", "Updates", recent)
+	preview.Title = "2026-10 Cumulative Update Preview for Windows 11 (KB1)"
+	definition := update("1", "Definition Updates", released)
+	ring := Ring{Name: "broad", Deferral: map[string]time.Duration{"Security Updates": 7 * 24 * time.Hour}}
+	excludes := enforcement.Enforcements{ExcludedDrivers: []enforcement.DriverExclude{
+		{DriverClass: "Display"},
+		{DriverDateVer: "2020-01-01"},
+	}}
+	for _, tt := range []struct {
+		desc       string
+		u          *updates.Update
+		s          Settings
+		e          enforcement.Enforcements
+		wantAction Action
+		wantReason Reason
+	}{
+		{"selected", update("1", "Security Updates", recent), Settings{RequiredCategories: []string{"Security Updates"}}, enforcement.Enforcements{}, Install, Selected},
+		{"any category", update("1", "Feature Packs", recent), Settings{}, enforcement.Enforcements{}, Install, Selected},
+		{"category mismatch", update("1", "Feature Packs", recent), Settings{RequiredCategories: []string{"Security Updates"}}, enforcement.Enforcements{}, Skip, CategoryMismatch},
+		{"kb requested", update("1", "Feature Packs", recent), Settings{KBs: kbs{"1"}}, enforcement.Enforcements{}, Install, Selected},
+		{"kb not requested", update("2", "Feature Packs", recent), Settings{KBs: kbs{"1"}}, enforcement.Enforcements{}, Skip, KBNotRequested},
+		{"empty kb filter", update("2", "Feature Packs", recent), Settings{KBs: kbs{}}, enforcement.Enforcements{}, Install, Selected},
+		{"driver class excluded", driver("Display", time.Time{}), Settings{}, excludes, Exclude, DriverExcluded},
+		{"driver date excluded", driver("Net", driverDate), Settings{}, excludes, Exclude, DriverExcluded},
+		{"driver not excluded", driver("Net", now), Settings{}, excludes, Install, Selected},
+		{"driver date range excluded", driver("Net", driverDate), Settings{}, enforcement.Enforcements{ExcludedDrivers: []enforcement.DriverExclude{{DriverClass: "N*", DriverDateBefore: "2020-06-01"}}}, Exclude, DriverExcluded},
+		{"invalid driver date ignored", driver("Net", now), Settings{}, enforcement.Enforcements{ExcludedDrivers: []enforcement.DriverExclude{{DriverDateVer: "yesterday"}}}, Install, Selected},
+		{"exclusion before category", driver("Display", now), Settings{RequiredCategories: []string{"Security Updates"}}, excludes, Exclude, DriverExcluded},
+		{"deadline reached", update("1", "Security Updates", old), Settings{DeadlineOnly: true, Deadline: 14 * 24 * time.Hour}, enforcement.Enforcements{}, Install, DeadlineReached},
+		{"deadline not reached", update("1", "Security Updates", recent), Settings{DeadlineOnly: true, Deadline: 14 * 24 * time.Hour}, enforcement.Enforcements{}, Defer, DeadlineNotReached},
+		{"deadline driver", driver("Net", now), Settings{DeadlineOnly: true}, enforcement.Enforcements{}, Defer, DriverMaintenanceOnly},
+		{"cve selected", cve("1", "CVE-2026-0001"), Settings{Select: enforcement.Selector{CVEs: []string{"cve-2026-0001"}}}, enforcement.Enforcements{}, Install, Selected},
+		{"cve not selected", cve("1", "CVE-2026-0002"), Settings{Select: enforcement.Selector{CVEs: []string{"CVE-2026-0001"}}}, enforcement.Enforcements{}, Skip, NotSelected},
+		{"kb or selector", cve("1", "CVE-2026-0002"), Settings{KBs: kbs{"1"}, Select: enforcement.Selector{CVEs: []string{"CVE-2026-0001"}}}, enforcement.Enforcements{}, Install, Selected},
+		{"category selected", update("1", "Feature Packs", recent), Settings{KBs: kbs{"2"}, Select: enforcement.Selector{Categories: []string{"Feature Packs"}}}, enforcement.Enforcements{}, Install, Selected},
+		{"ring deferred", update("1", "Security Updates", recent), Settings{Ring: ring}, enforcement.Enforcements{}, Defer, RingDeferred},
+		{"ring available", update("1", "Security Updates", old), Settings{Ring: ring}, enforcement.Enforcements{}, Install, Selected},
+		{"ring category not deferred", update("1", "Feature Packs", recent), Settings{Ring: ring}, enforcement.Enforcements{}, Install, Selected},
+		{"ring kb not deferred", update("1", "Security Updates", recent), Settings{Ring: ring, KBs: kbs{"1"}}, enforcement.Enforcements{}, Install, Selected},
+		{"ring deadline from availability", update("1", "Security Updates", now.Add(-20*24*time.Hour)), Settings{Ring: ring, DeadlineOnly: true, Deadline: 14 * 24 * time.Hour}, enforcement.Enforcements{}, Defer, DeadlineNotReached},
+		{"patch tuesday deferred", update("1", "Security Updates", released), Settings{PatchDelay: week}, enforcement.Enforcements{}, Defer, PatchTuesdayDeferred},
+		{"patch tuesday passed", update("1", "Security Updates", released), Settings{PatchDelay: twoDays}, enforcement.Enforcements{}, Install, Selected},
+		{"patch tuesday kb not deferred", update("1", "Security Updates", released), Settings{PatchDelay: week, KBs: kbs{"1"}}, enforcement.Enforcements{}, Install, Selected},
+		{"patch tuesday definitions not deferred", definition, Settings{PatchDelay: week}, enforcement.Enforcements{}, Install, Selected},
+		{"ring later than patch tuesday", update("1", "Security Updates", released), Settings{PatchDelay: week, Ring: ring}, enforcement.Enforcements{}, Defer, RingDeferred},
+		{"patch tuesday deadline", update("1", "Security Updates", released), Settings{PatchDelay: twoDays, DeadlineOnly: true, Deadline: 3 * 24 * time.Hour}, enforcement.Enforcements{}, Defer, DeadlineNotReached},
+		{"preview skipped", preview, Settings{SkipPreviews: true}, enforcement.Enforcements{}, Skip, PreviewRelease},
+		{"preview kept", preview, Settings{}, enforcement.Enforcements{}, Install, Selected},
+		{"preview kb requested", preview, Settings{SkipPreviews: true, KBs: kbs{"1"}}, enforcement.Enforcements{}, Install, Selected},
+		{"hidden by title", cve("1", "CVE-2026-0001"), Settings{Select: enforcement.Selector{CVEs: []string{"CVE-2026-0001"}}}, enforcement.Enforcements{HiddenTitleRegex: []string{`^KB\d+$`}}, Exclude, HiddenBySelector},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			tt.s.Now = now
+			ds := Evaluate([]*updates.Update{tt.u}, tt.s, tt.e)
+			if len(ds) != 1 {
+				t.Fatalf("Evaluate() returned %d decisions, want 1", len(ds))
+			}
+			if ds[0].Action != tt.wantAction || ds[0].Reason != tt.wantReason {
+				t.Errorf("Evaluate() = %s/%s, want %s/%s", ds[0].Action, ds[0].Reason, tt.wantAction, tt.wantReason)
+			}
+			if ds[0].Reason == DriverExcluded && ds[0].Rule == nil {
+				t.Errorf("Evaluate() returned no rule for a driver exclusion")
+			}
+		})
+	}
+}
+
+func TestToInstall(t *testing.T) {
+	a := update("1", "Security Updates", now)
+	b := update("2", "Feature Packs", now)
+	ds := Evaluate([]*updates.Update{a, b}, Settings{RequiredCategories: []string{"Security Updates"}, Now: now}, enforcement.Enforcements{})
+	got := ToInstall(ds)
+	if len(got) != 1 || got[0] != a {
+		t.Errorf("ToInstall() = %v, want [%v]", got, a)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	for _, tt := range []struct {
+		d    Decision
+		want string
+	}{
+		{Decision{Reason: DeadlineReached, Deadline: now.Add(-3 * 24 * time.Hour)}, "deadline reached 3 days ago"},
+		{Decision{Reason: DeadlineReached, Deadline: now.Add(-30 * time.Hour)}, "deadline reached 1 day ago"},
+		{Decision{Reason: DeadlineReached, Deadline: now.Add(-time.Hour)}, "deadline reached earlier today"},
+		{Decision{Reason: DeadlineNotReached, Deadline: now.Add(5*24*time.Hour + time.Hour)}, "deadline in 5 days"},
+		{Decision{Reason: CategoryMismatch}, "not in RequiredCategories"},
+		{Decision{Reason: InstallByReached, Deadline: now.Add(-2*24*time.Hour - time.Hour), Origins: []enforcement.Origin{{Path: "required.json"}}}, "required by enforcement in required.json, install-by deadline reached 2 days ago"},
+		{Decision{Reason: HiddenByEnforcement, Origins: []enforcement.Origin{{Path: "a.json"}, {Path: "b.json"}}}, "hidden by enforcement in a.json, b.json"},
+		{Decision{Reason: EnforcementRequired}, "required by enforcement"},
+		{Decision{Reason: BlackoutHeld, Deadline: now.Add(3*24*time.Hour + time.Hour)}, "held by a blackout period, available in 3 days"},
+		{Decision{Reason: PatchTuesdayDeferred, Deadline: now.Add(3*24*time.Hour + time.Hour)}, "deferred after Patch Tuesday, available in 3 days"},
+		{Decision{Reason: RingDeferred, Deadline: now.Add(2*24*time.Hour + time.Hour)}, "deferred by the deployment ring, available in 2 days"},
+		{Decision{Reason: DriverExcluded, Rule: &enforcement.DriverExclude{DriverClass: "Display"}}, `excluded by driver rule driver-class="Display"`},
+		{Decision{Reason: DriverExcluded, Rule: &enforcement.DriverExclude{DriverClass: "Display"}, Origins: []enforcement.Origin{{Path: filepath.Join("enforcement", "excluded-drivers.json")}}}, `excluded by driver rule driver-class="Display" in excluded-drivers.json`},
+	} {
+		if got := tt.d.Explain(now); got != tt.want {
+			t.Errorf("Explain(%s) = %q, want %q", tt.d.Reason, got, tt.want)
+		}
+	}
+}
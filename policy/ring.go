@@ -0,0 +1,102 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cabbie/updates"
+)
+
+// Ring is a deployment ring of a staged rollout. Updates become available to
+// the ring a per-category deferral after their LastDeploymentChangeTime.
+type Ring struct {
+	Name string
+	// Deferral maps categories to how long their updates are deferred. An
+	// update in several categories is deferred by the longest of them, and
+	// one in none of them is not deferred.
+	Deferral map[string]time.Duration
+}
+
+// Available returns when u becomes available to the ring.
+func (r Ring) Available(u *updates.Update) time.Time {
+	var d time.Duration
+	for _, c := range u.Categories {
+		if v, ok := r.Deferral[c.Name]; ok && v > d {
+			d = v
+		}
+	}
+	return u.LastDeploymentChangeTime.Add(d)
+}
+
+// ParseDeferral parses a ring deferral of the form ring:category=days.
+func ParseDeferral(s string) (ring, category string, d time.Duration, err error) {
+	rc, days, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", "", 0, fmt.Errorf("deferral %q is not of the form ring:category=days", s)
+	}
+	ring, category, ok = strings.Cut(rc, ":")
+	ring, category = strings.TrimSpace(ring), strings.TrimSpace(category)
+	if !ok || ring == "" || category == "" {
+		return "", "", 0, fmt.Errorf("deferral %q is not of the form ring:category=days", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(days))
+	if err != nil || n < 0 {
+		return "", "", 0, fmt.Errorf("deferral %q: days must be a non-negative integer", s)
+	}
+	return ring, category, time.Duration(n) * 24 * time.Hour, nil
+}
+
+// RingShare is the percentage of hosts assigned to a ring, see HostRing.
+type RingShare struct {
+	Ring    string
+	Percent int
+}
+
+// ParseRingShare parses a ring share of the form ring=percent.
+func ParseRingShare(s string) (RingShare, error) {
+	ring, pct, ok := strings.Cut(s, "=")
+	ring = strings.TrimSpace(ring)
+	if !ok || ring == "" {
+		return RingShare{}, fmt.Errorf("ring share %q is not of the form ring=percent", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(pct))
+	if err != nil || n < 0 || n > 100 {
+		return RingShare{}, fmt.Errorf("ring share %q: percent must be an integer between 0 and 100", s)
+	}
+	return RingShare{Ring: ring, Percent: n}, nil
+}
+
+// HostRing assigns host to one of the rings of shares, in order, from a hash
+// of its name. A host keeps its ring as long as the shares before it do not
+// change. Hosts beyond the total percentage are in no ring, and "" is
+// returned for them.
+func HostRing(host string, shares []RingShare) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(host)))
+	bucket := int(h.Sum32() % 100)
+	total := 0
+	for _, s := range shares {
+		total += s.Percent
+		if bucket < total {
+			return s.Ring
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/updates"
+)
+
+func TestAvailable(t *testing.T) {
+	day := 24 * time.Hour
+	r := Ring{Name: "early", Deferral: map[string]time.Duration{"Security Updates": 3 * day, "Critical Updates": 5 * day}}
+	both := update("1", "Security Updates", now)
+	both.Categories = append(both.Categories, updates.Category{Name: "Critical Updates"})
+	for _, tt := range []struct {
+		u    *updates.Update
+		want time.Time
+	}{
+		{update("1", "Security Updates", now), now.Add(3 * day)},
+		{both, now.Add(5 * day)},
+		{update("1", "Feature Packs", now), now},
+	} {
+		if got := r.Available(tt.u); !got.Equal(tt.want) {
+			t.Errorf("Available(%v) = %v, want %v", tt.u.Categories, got, tt.want)
+		}
+	}
+}
+
+func TestParseDeferral(t *testing.T) {
+	for _, tt := range []struct {
+		in           string
+		wantRing     string
+		wantCategory string
+		want         time.Duration
+		wantErr      bool
+	}{
+		{"canary:Security Updates=0", "canary", "Security Updates", 0, false},
+		{" broad : Critical Updates = 14 ", "broad", "Critical Updates", 14 * 24 * time.Hour, false},
+		{"broad=14", "", "", 0, true},
+		{"broad:Security Updates", "", "", 0, true},
+		{":Security Updates=3", "", "", 0, true},
+		{"broad:Security Updates=-1", "", "", 0, true},
+		{"broad:Security Updates=2d", "", "", 0, true},
+	} {
+		ring, category, d, err := ParseDeferral(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDeferral(%q) returned error %v, want error: %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if ring != tt.wantRing || category != tt.wantCategory || d != tt.want {
+			t.Errorf("ParseDeferral(%q) = %q, %q, %v, want %q, %q, %v", tt.in, ring, category, d, tt.wantRing, tt.wantCategory, tt.want)
+		}
+	}
+}
+
+func TestParseRingShare(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    RingShare
+		wantErr bool
+	}{
+		{"canary=5", RingShare{"canary", 5}, false},
+		{" early = 20 ", RingShare{"early", 20}, false},
+		{"canary", RingShare{}, true},
+		{"=5", RingShare{}, true},
+		{"canary=101", RingShare{}, true},
+		{"canary=five", RingShare{}, true},
+	} {
+		got, err := ParseRingShare(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRingShare(%q) returned error %v, want error: %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRingShare(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHostRing(t *testing.T) {
+	shares := []RingShare{{"canary", 10}, {"early", 30}, {"broad", 60}}
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		host := fmt.Sprintf("host-%04d", i)
+		r := HostRing(host, shares)
+		if again := HostRing(host, shares); again != r {
+			t.Fatalf("HostRing(%q) = %q, then %q", host, r, again)
+		}
+		counts[r]++
+	}
+	for _, s := range shares {
+		// Allow for the spread of the hash over a small sample.
+		if want := s.Percent * 10; counts[s.Ring] < want/2 || counts[s.Ring] > want*3/2 {
+			t.Errorf("HostRing() assigned %d of 1000 hosts to %q, want about %d", counts[s.Ring], s.Ring, want)
+		}
+	}
+	if counts[""] != 0 {
+		t.Errorf("HostRing() assigned %d hosts to no ring with shares totalling 100%%", counts[""])
+	}
+	if got := HostRing("HOST-0001", shares); got != HostRing("host-0001", shares) {
+		t.Errorf("HostRing() depends on the case of the host name")
+	}
+	if got := HostRing("host-0001", nil); got != "" {
+		t.Errorf("HostRing() with no shares = %q, want \"\"", got)
+	}
+}
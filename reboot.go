@@ -17,8 +17,11 @@ import (
 	"golang.org/x/net/context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"flag"
@@ -32,9 +35,17 @@ import (
 
 // Available flags
 type rebootCmd struct {
-	clear bool
-	time  uint64 // time in seconds until reboot
-	check bool
+	clear  bool
+	time   uint64 // time in seconds until reboot
+	check  bool
+	format string
+}
+
+// rebootRecord is the machine-readable form of the pending reboot status.
+type rebootRecord struct {
+	Pending       bool
+	RebootTime    *time.Time `json:",omitempty"`
+	RebootUpdates []string
 }
 
 func (rebootCmd) Name() string { return "reboot" }
@@ -42,12 +53,13 @@ func (rebootCmd) Synopsis() string {
 	return "manually set or clear the Cabbie reboot time Registry key."
 }
 func (rebootCmd) Usage() string {
-	return fmt.Sprintf("%s reboot [--check | --clear | --time <seconds>]\n", filepath.Base(os.Args[0]))
+	return fmt.Sprintf("%s reboot [--check [--format=text|json|csv|table] | --clear | --time <seconds>]\n", filepath.Base(os.Args[0]))
 }
 func (c *rebootCmd) SetFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.clear, "clear", false, "Clear the reboot time if set.")
 	f.Uint64Var(&c.time, "time", 0, "Set the reboot time in seconds.")
 	f.BoolVar(&c.check, "check", false, "Check if a reboot is pending, and display the time if present.")
+	f.StringVar(&c.format, "format", formatText, "Output format of --check, one of text, json, csv or table.")
 }
 
 func (c rebootCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subcommands.ExitStatus {
@@ -58,6 +70,10 @@ func (c rebootCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) sub
 		fmt.Println("One of --clear, --time (non-zero), or --check must be set.")
 		return subcommands.ExitFailure
 	}
+	if err := vetFormat(c.format, formatText, formatJSON, formatCSV, formatTable); err != nil {
+		fmt.Printf("%v\n%s\n", err, c.Usage())
+		return subcommands.ExitUsageError
+	}
 	if c.clear {
 		if err := notification.CleanNotifications(cablib.SvcName); err != nil {
 			deck.ErrorfA("Failed to clear reboot notification: %v", err).With(eventID(cablib.EvtErrNotifications)).Go()
@@ -89,6 +105,20 @@ func (c rebootCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) sub
 		deck.InfoA(msg).With(eventID(cablib.EvtRebootRequired)).Go()
 		fmt.Print(msg)
 	}
+	if c.check && c.format != formatText {
+		r, err := rebootStatus()
+		if err != nil {
+			msg := fmt.Sprintf("Failed to get reboot pending status: %v", err)
+			deck.ErrorfA(msg).With(eventID(cablib.EvtMisc)).Go()
+			fmt.Println(msg)
+			return subcommands.ExitFailure
+		}
+		if err := writeRebootStatus(os.Stdout, c.format, r); err != nil {
+			fmt.Printf("Failed to write reboot status: %v\n", err)
+			return subcommands.ExitFailure
+		}
+		return rc
+	}
 	if c.check {
 		pending, err := cablib.RebootRequired()
 		if err != nil {
@@ -116,3 +146,38 @@ func (c rebootCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) sub
 	}
 	return rc
 }
+
+// rebootStatus collects the pending reboot time and the KBs that require it.
+func rebootStatus() (*rebootRecord, error) {
+	pending, err := cablib.RebootRequired()
+	if err != nil {
+		return nil, err
+	}
+	r := &rebootRecord{Pending: pending, RebootUpdates: []string{}}
+	if !pending {
+		return r, nil
+	}
+	t, err := cablib.RebootTime()
+	if err != nil {
+		return nil, fmt.Errorf("a reboot is pending, but failed to get reboot time: %v", err)
+	}
+	if !t.IsZero() {
+		r.RebootTime = &t
+	}
+	kbs, err := cablib.GetRebootUpdates()
+	if err != nil {
+		return nil, err
+	}
+	r.RebootUpdates = append(r.RebootUpdates, kbs...)
+	return r, nil
+}
+
+func writeRebootStatus(w io.Writer, format string, r *rebootRecord) error {
+	var t time.Time
+	if r.RebootTime != nil {
+		t = *r.RebootTime
+	}
+	header := []string{"pending", "reboot_time", "kbs"}
+	rows := [][]string{{strconv.FormatBool(r.Pending), formatTime(t), strings.Join(r.RebootUpdates, ";")}}
+	return writeRecords(w, format, r, header, rows)
+}
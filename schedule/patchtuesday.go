@@ -0,0 +1,91 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxPatchDays is the longest delay after Patch Tuesday, so that the install
+// day of a month always comes before the next Patch Tuesday.
+const MaxPatchDays = 27
+
+// PatchTuesday returns the second Tuesday of month, when Microsoft releases
+// its monthly security updates, at midnight in loc.
+func PatchTuesday(year int, month time.Month, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	offset := (int(time.Tuesday) - int(first.Weekday()) + 7) % 7
+	return time.Date(year, month, 1+offset+7, 0, 0, 0, 0, loc)
+}
+
+// PatchDelay holds updates until a number of days after Patch Tuesday.
+type PatchDelay struct {
+	days int
+	loc  *time.Location
+}
+
+// NewPatchDelay returns a delay of days after Patch Tuesday, counted in the
+// IANA time zone tz. An empty tz is the local time zone.
+func NewPatchDelay(days int, tz string) (*PatchDelay, error) {
+	if days < 0 || days > MaxPatchDays {
+		return nil, fmt.Errorf("%d days after Patch Tuesday is not between 0 and %d", days, MaxPatchDays)
+	}
+	loc, err := location(tz)
+	if err != nil {
+		return nil, err
+	}
+	return &PatchDelay{days: days, loc: loc}, nil
+}
+
+func (d *PatchDelay) String() string {
+	return fmt.Sprintf("%d days after Patch Tuesday in %s", d.days, d.loc)
+}
+
+// Release returns the Patch Tuesday of the release cycle t is in, the last
+// one at or before t.
+func (d *PatchDelay) Release(t time.Time) time.Time {
+	t = t.In(d.loc)
+	pt := PatchTuesday(t.Year(), t.Month(), d.loc)
+	if t.Before(pt) {
+		prev := time.Date(t.Year(), t.Month()-1, 1, 0, 0, 0, 0, d.loc)
+		pt = PatchTuesday(prev.Year(), prev.Month(), d.loc)
+	}
+	return pt
+}
+
+// Available returns when an update deployed at t may be installed: the day
+// set by the delay in its release cycle, or t itself if that has passed.
+// Days are calendar days, so that the install day starts at midnight even
+// across a daylight saving time change.
+func (d *PatchDelay) Available(t time.Time) time.Time {
+	a := d.Release(t).AddDate(0, 0, d.days)
+	if a.Before(t) {
+		return t
+	}
+	return a
+}
+
+// Next returns the first install day after t.
+func (d *PatchDelay) Next(t time.Time) time.Time {
+	t = t.In(d.loc)
+	// The install day of the previous month may not have come yet.
+	for i := -1; ; i++ {
+		m := time.Date(t.Year(), t.Month()+time.Month(i), 1, 0, 0, 0, 0, d.loc)
+		if a := PatchTuesday(m.Year(), m.Month(), d.loc).AddDate(0, 0, d.days); a.After(t) {
+			return a
+		}
+	}
+}
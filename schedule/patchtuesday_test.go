@@ -0,0 +1,143 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPatchTuesday(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	for _, tt := range []struct {
+		year  int
+		month time.Month
+		want  int
+	}{
+		{2026, time.January, 13},
+		{2026, time.February, 10},
+		// Months starting on a Tuesday, and on the day after.
+		{2026, time.September, 8},
+		{2026, time.July, 14},
+		{2026, time.December, 8},
+		{2027, time.January, 12},
+	} {
+		got := PatchTuesday(tt.year, tt.month, ny)
+		want := time.Date(tt.year, tt.month, tt.want, 0, 0, 0, 0, ny)
+		if !got.Equal(want) || got.Weekday() != time.Tuesday {
+			t.Errorf("PatchTuesday(%d, %s) = %v, want %v", tt.year, tt.month, got, want)
+		}
+	}
+}
+
+func TestNewPatchDelay(t *testing.T) {
+	for _, tt := range []struct {
+		days    int
+		tz      string
+		wantErr bool
+	}{
+		{0, "", false},
+		{7, "America/New_York", false},
+		{MaxPatchDays, "UTC", false},
+		{-1, "", true},
+		{MaxPatchDays + 1, "", true},
+		{7, "Mars/Olympus_Mons", true},
+	} {
+		_, err := NewPatchDelay(tt.days, tt.tz)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewPatchDelay(%d, %q) returned error %v, want error: %t", tt.days, tt.tz, err, tt.wantErr)
+		}
+	}
+}
+
+func mustDelay(t *testing.T, days int, tz string) *PatchDelay {
+	t.Helper()
+	d, err := NewPatchDelay(days, tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestRelease(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	for _, tt := range []struct {
+		desc string
+		tz   string
+		in   time.Time
+		want time.Time
+	}{
+		{"on patch tuesday", "America/New_York", time.Date(2026, 10, 13, 0, 0, 0, 0, ny), time.Date(2026, 10, 13, 0, 0, 0, 0, ny)},
+		{"after patch tuesday", "America/New_York", time.Date(2026, 10, 27, 13, 0, 0, 0, ny), time.Date(2026, 10, 13, 0, 0, 0, 0, ny)},
+		{"before patch tuesday", "America/New_York", time.Date(2026, 10, 12, 23, 59, 0, 0, ny), time.Date(2026, 9, 8, 0, 0, 0, 0, ny)},
+		{"across the year", "America/New_York", time.Date(2027, 1, 5, 0, 0, 0, 0, ny), time.Date(2026, 12, 8, 0, 0, 0, 0, ny)},
+		// 02:00 UTC is still the day before in New York.
+		{"in the time zone", "America/New_York", time.Date(2026, 10, 13, 2, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 0, 0, 0, 0, ny)},
+		{"in UTC", "UTC", time.Date(2026, 10, 13, 2, 0, 0, 0, time.UTC), time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)},
+	} {
+		if got := mustDelay(t, 0, tt.tz).Release(tt.in); !got.Equal(tt.want) {
+			t.Errorf("Release(%v) = %v, want %v (%s)", tt.in, got, tt.want, tt.desc)
+		}
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	london := mustLoad(t, "Europe/London")
+	for _, tt := range []struct {
+		desc string
+		days int
+		tz   string
+		in   time.Time
+		want time.Time
+	}{
+		{"b release", 7, "America/New_York", time.Date(2026, 10, 13, 13, 0, 0, 0, ny), time.Date(2026, 10, 20, 0, 0, 0, 0, ny)},
+		{"out of band after the delay", 7, "America/New_York", time.Date(2026, 10, 24, 9, 0, 0, 0, ny), time.Date(2026, 10, 24, 9, 0, 0, 0, ny)},
+		{"same day", 0, "America/New_York", time.Date(2026, 10, 13, 13, 0, 0, 0, ny), time.Date(2026, 10, 13, 13, 0, 0, 0, ny)},
+		{"into the next month", 20, "America/New_York", time.Date(2026, 9, 8, 13, 0, 0, 0, ny), time.Date(2026, 9, 28, 0, 0, 0, 0, ny)},
+		{"into the next year", 10, "America/New_York", time.Date(2026, 12, 8, 13, 0, 0, 0, ny), time.Date(2026, 12, 18, 0, 0, 0, 0, ny)},
+		// Midnight is 04:00 UTC after the clocks go forward on March 8, not 05:00.
+		{"dst starts", 27, "America/New_York", time.Date(2026, 2, 10, 13, 0, 0, 0, ny), time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC)},
+		// And 05:00 UTC after they go back on November 1.
+		{"dst ends", 20, "America/New_York", time.Date(2026, 10, 13, 13, 0, 0, 0, ny), time.Date(2026, 11, 2, 5, 0, 0, 0, time.UTC)},
+		{"summer time starts", 20, "Europe/London", time.Date(2026, 3, 10, 18, 0, 0, 0, london), time.Date(2026, 3, 29, 23, 0, 0, 0, time.UTC)},
+	} {
+		got := mustDelay(t, tt.days, tt.tz).Available(tt.in)
+		if !got.Equal(tt.want) {
+			t.Errorf("Available(%v) with %d days = %v, want %v (%s)", tt.in, tt.days, got, tt.want, tt.desc)
+		}
+	}
+}
+
+func TestNextInstallDay(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	for _, tt := range []struct {
+		desc string
+		days int
+		in   time.Time
+		want time.Time
+	}{
+		{"this month", 7, time.Date(2026, 10, 1, 12, 0, 0, 0, ny), time.Date(2026, 10, 20, 0, 0, 0, 0, ny)},
+		{"at the install day", 7, time.Date(2026, 10, 20, 0, 0, 0, 0, ny), time.Date(2026, 11, 17, 0, 0, 0, 0, ny)},
+		{"next month", 7, time.Date(2026, 10, 21, 0, 0, 0, 0, ny), time.Date(2026, 11, 17, 0, 0, 0, 0, ny)},
+		{"previous month's not passed", MaxPatchDays, time.Date(2026, 11, 1, 0, 0, 0, 0, ny), time.Date(2026, 11, 9, 0, 0, 0, 0, ny)},
+		{"across the year", 5, time.Date(2026, 12, 20, 0, 0, 0, 0, ny), time.Date(2027, 1, 17, 0, 0, 0, 0, ny)},
+		{"across dst", 27, time.Date(2026, 3, 1, 0, 0, 0, 0, ny), time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC)},
+	} {
+		if got := mustDelay(t, tt.days, "America/New_York").Next(tt.in); !got.Equal(tt.want) {
+			t.Errorf("Next(%v) with %d days = %v, want %v (%s)", tt.in, tt.days, got, tt.want, tt.desc)
+		}
+	}
+}
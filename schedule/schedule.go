@@ -0,0 +1,139 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schedule computes maintenance windows from a cron expression, so
+// that Cabbie can install updates at fixed times without Aukera, and the days
+// updates are installed on relative to Patch Tuesday.
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	// Windows has no IANA time zone database, so it is embedded.
+	_ "time/tzdata"
+)
+
+// parser accepts expressions with or without a leading seconds field, and
+// descriptors such as @weekly.
+var parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Window is a period in which updates may be installed.
+type Window struct {
+	Opens  time.Time
+	Closes time.Time
+}
+
+// Contains returns true if the window is open at t.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Opens) && t.Before(w.Closes)
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("%s - %s", w.Opens.Format(time.RFC3339), w.Closes.Format(time.RFC3339))
+}
+
+// Event is a window opening or closing.
+type Event struct {
+	Time   time.Time
+	Open   bool
+	Window Window
+}
+
+// Schedule opens a window of a fixed duration at each time matched by a cron
+// expression.
+type Schedule struct {
+	expr     string
+	spec     cron.Schedule
+	duration time.Duration
+	loc      *time.Location
+}
+
+// location loads the IANA time zone tz, or the local time zone if empty.
+func location(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %v", tz, err)
+	}
+	return loc, nil
+}
+
+// New returns a schedule of windows lasting d, opening at the times matched
+// by expr in the IANA time zone tz. An empty tz is the local time zone.
+func New(expr string, d time.Duration, tz string) (*Schedule, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("window duration %v is not positive", d)
+	}
+	loc, err := location(tz)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := parser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", expr, err)
+	}
+	// The expression is evaluated in tz, whatever the time it is given.
+	if s, ok := spec.(*cron.SpecSchedule); ok {
+		s.Location = loc
+	}
+	return &Schedule{expr: expr, spec: spec, duration: d, loc: loc}, nil
+}
+
+func (s *Schedule) String() string {
+	return fmt.Sprintf("%q for %v in %s", s.expr, s.duration, s.loc)
+}
+
+// Window returns the window open at t or, if none is, the next one to open.
+// The zero Window is returned if the expression never matches again.
+func (s *Schedule) Window(t time.Time) Window {
+	// The first opening after t-duration is the one open at t, if any.
+	o := s.spec.Next(t.Add(-s.duration))
+	if o.IsZero() {
+		return Window{}
+	}
+	return Window{Opens: o, Closes: o.Add(s.duration)}
+}
+
+// Next returns the first time after t that a window opens or closes. The
+// event has a zero Time if no window opens again.
+func (s *Schedule) Next(t time.Time) Event {
+	w := s.Window(t)
+	if w.Contains(t) {
+		return Event{Time: w.Closes, Window: w}
+	}
+	return Event{Time: w.Opens, Open: true, Window: w}
+}
+
+// Start returns the first event to act on at t: the opening of the window
+// open at t, reported as of t, or else the next event.
+func (s *Schedule) Start(t time.Time) Event {
+	if w := s.Window(t); w.Contains(t) {
+		return Event{Time: t, Open: true, Window: w}
+	}
+	return s.Next(t)
+}
+
+// Following returns the event after e. A window that opens as the previous
+// one closes is reported as opening.
+func (s *Schedule) Following(e Event) Event {
+	if e.Open {
+		return s.Next(e.Time)
+	}
+	return s.Start(e.Time)
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func mustLoad(t *testing.T, tz string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc    string
+		expr    string
+		d       time.Duration
+		tz      string
+		wantErr bool
+	}{
+		{"with seconds", "0 0 2 * * SAT", 4 * time.Hour, "America/New_York", false},
+		{"without seconds", "0 2 * * SAT", 4 * time.Hour, "", false},
+		{"descriptor", "@weekly", time.Hour, "UTC", false},
+		{"bad expression", "0 0 25 * * SAT", 4 * time.Hour, "", true},
+		{"bad time zone", "0 0 2 * * SAT", 4 * time.Hour, "Mars/Olympus_Mons", true},
+		{"no duration", "0 0 2 * * SAT", 0, "", true},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.expr, tt.d, tt.tz)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q, %v, %q) returned error %v, want error: %t (%s)", tt.expr, tt.d, tt.tz, err, tt.wantErr, tt.desc)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	s, err := New("0 0 2 * * SAT", 4*time.Hour, "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saturday 2026-10-17 02:00 in New York.
+	opens := time.Date(2026, 10, 17, 2, 0, 0, 0, ny)
+	this := Window{Opens: opens, Closes: opens.Add(4 * time.Hour)}
+	next := Window{Opens: opens.AddDate(0, 0, 7), Closes: opens.AddDate(0, 0, 7).Add(4 * time.Hour)}
+	tests := []struct {
+		desc string
+		in   time.Time
+		want Window
+		open bool
+	}{
+		{"before", opens.Add(-time.Hour), this, false},
+		{"opening", opens, this, true},
+		{"inside, in another zone", opens.Add(time.Hour).UTC(), this, true},
+		{"closing", opens.Add(4 * time.Hour), next, false},
+		{"after", opens.Add(5 * time.Hour), next, false},
+	}
+	for _, tt := range tests {
+		got := s.Window(tt.in)
+		if !got.Opens.Equal(tt.want.Opens) || !got.Closes.Equal(tt.want.Closes) {
+			t.Errorf("Window(%v) = %v, want %v (%s)", tt.in, got, tt.want, tt.desc)
+		}
+		if got.Contains(tt.in) != tt.open {
+			t.Errorf("Window(%v).Contains(%v) = %t, want %t (%s)", tt.in, tt.in, !tt.open, tt.open, tt.desc)
+		}
+	}
+}
+
+// events returns the first n events from start, as a service loop sees them.
+func events(s *Schedule, start time.Time, n int) []Event {
+	var es []Event
+	for e := s.Start(start); len(es) < n; e = s.Following(e) {
+		es = append(es, e)
+	}
+	return es
+}
+
+func TestEvents(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 10, 17, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		desc  string
+		expr  string
+		d     time.Duration
+		start time.Time
+		want  []Event
+	}{
+		{
+			"started before a window",
+			"0 0 2 * * *", time.Hour, at(1, 0),
+			[]Event{
+				{Time: at(2, 0), Open: true, Window: Window{at(2, 0), at(3, 0)}},
+				{Time: at(3, 0), Window: Window{at(2, 0), at(3, 0)}},
+				{Time: at(2, 0).AddDate(0, 0, 1), Open: true, Window: Window{at(2, 0).AddDate(0, 0, 1), at(3, 0).AddDate(0, 0, 1)}},
+			},
+		},
+		{
+			"started inside a window",
+			"0 0 2 * * *", time.Hour, at(2, 30),
+			[]Event{
+				{Time: at(2, 30), Open: true, Window: Window{at(2, 0), at(3, 0)}},
+				{Time: at(3, 0), Window: Window{at(2, 0), at(3, 0)}},
+			},
+		},
+		{
+			"back to back windows",
+			"0 0 * * * *", time.Hour, at(1, 0),
+			[]Event{
+				{Time: at(1, 0), Open: true, Window: Window{at(1, 0), at(2, 0)}},
+				{Time: at(2, 0), Window: Window{at(1, 0), at(2, 0)}},
+				{Time: at(2, 0), Open: true, Window: Window{at(2, 0), at(3, 0)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		s, err := New(tt.expr, tt.d, "UTC")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, events(s, tt.start, len(tt.want))); diff != "" {
+			t.Errorf("events(%q) returned unexpected diff (-want +got):\n%s (%s)", tt.expr, diff, tt.desc)
+		}
+	}
+}
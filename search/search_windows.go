@@ -163,9 +163,10 @@ func (s *Searcher) GetTotalHistoryCount() (int, error) {
 	return int(c.Val), nil
 }
 
-// QueryHistory synchronously queries the computer for the history of the update events.
-func (s *Searcher) QueryHistory(count int) (*ole.IDispatch, error) {
-	h, err := oleutil.CallMethod(s.IUpdateSearcher, "QueryHistory", 0, count)
+// QueryHistory synchronously queries the computer for count update events,
+// starting at index start of the most recent first history.
+func (s *Searcher) QueryHistory(start, count int) (*ole.IDispatch, error) {
+	h, err := oleutil.CallMethod(s.IUpdateSearcher, "QueryHistory", start, count)
 	if err != nil {
 		return nil, fmt.Errorf("error querying list of installed updates: %v", err)
 	}
@@ -0,0 +1,132 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"golang.org/x/net/context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"flag"
+	"github.com/google/cabbie/jobs"
+	"github.com/google/subcommands"
+)
+
+// Available flags
+type statusCmd struct {
+	format string
+}
+
+// jobRecord is the machine-readable form of the state of a service job.
+type jobRecord struct {
+	Job         jobs.Job
+	Enabled     bool
+	Interval    string
+	Splay       string
+	Jitter      string
+	LastRun     *time.Time `json:",omitempty"`
+	LastSuccess *time.Time `json:",omitempty"`
+	// NextRun is the earliest time the job runs next, before jitter.
+	NextRun *time.Time `json:",omitempty"`
+	Error   string     `json:",omitempty"`
+}
+
+func (statusCmd) Name() string     { return "status" }
+func (statusCmd) Synopsis() string { return "Show when the service jobs last ran and run next." }
+func (statusCmd) Usage() string {
+	return fmt.Sprintf("%s status [--format=table|json|csv]\n", filepath.Base(os.Args[0]))
+}
+
+func (c *statusCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.format, "format", formatTable, "Output format, one of table, json or csv.")
+}
+
+func (c *statusCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	if err := vetFormat(c.format, formatTable, formatJSON, formatCSV); err != nil {
+		fmt.Printf("%v\n%s\nUsage: %s\n", err, c.Synopsis(), c.Usage())
+		return subcommands.ExitUsageError
+	}
+	if err := writeStatus(os.Stdout, c.format, jobs.Registry{}, time.Now()); err != nil {
+		fmt.Printf("Failed to write status: %v\n", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+// jobEnabled returns true if the service runs j with the current settings.
+func jobEnabled(j jobs.Job) bool {
+	switch j {
+	case jobs.Install:
+		// Maintenance windows replace the install job.
+		return config.AukeraEnabled == 0 && config.Schedule == ""
+	case jobs.Virus:
+		return config.InstallVirusDefs == 1
+	case jobs.Driver:
+		return config.InstallDrivers == 1
+	case jobs.Remote:
+		return config.EnforcementURL != ""
+	}
+	return true
+}
+
+// jobRecords returns the state of every service job, as recorded in s.
+func jobRecords(s jobs.Store, now time.Time) []jobRecord {
+	var rs []jobRecord
+	for _, j := range jobs.Jobs {
+		interval := config.Intervals[j]
+		spread := config.jobSpread(j).Cap(interval)
+		r := jobRecord{Job: j, Enabled: jobEnabled(j), Interval: interval.String(), Splay: spread.Splay.String(), Jitter: spread.Jitter.String()}
+		rec, err := s.Load(j)
+		if err != nil {
+			r.Error = err.Error()
+			rs = append(rs, r)
+			continue
+		}
+		if !rec.LastRun.IsZero() {
+			r.LastRun = &rec.LastRun
+		}
+		if !rec.LastSuccess.IsZero() {
+			r.LastSuccess = &rec.LastSuccess
+		}
+		if r.Enabled {
+			next := jobs.Next(rec, interval, 0, now).Add(spread.Splay)
+			r.NextRun = &next
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+// writeStatus writes the state of the service jobs to w in format.
+func writeStatus(w io.Writer, format string, s jobs.Store, now time.Time) error {
+	rs := jobRecords(s, now)
+	header := []string{"job", "enabled", "interval", "splay", "jitter", "last_run", "last_success", "next_run", "error"}
+	var rows [][]string
+	for _, r := range rs {
+		rows = append(rows, []string{string(r.Job), fmt.Sprint(r.Enabled), r.Interval, r.Splay, r.Jitter,
+			formatTimePtr(r.LastRun), formatTimePtr(r.LastSuccess), formatTimePtr(r.NextRun), r.Error})
+	}
+	return writeRecords(w, format, rs, header, rows)
+}
+
+// formatTimePtr renders t like formatTime, leaving nil times empty.
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
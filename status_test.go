@@ -0,0 +1,83 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/jobs"
+)
+
+// fakeJobStore returns fixed job records.
+type fakeJobStore map[jobs.Job]jobs.Record
+
+func (s fakeJobStore) Load(j jobs.Job) (jobs.Record, error) {
+	if j == jobs.Virus {
+		return jobs.Record{}, errors.New("unreadable")
+	}
+	return s[j], nil
+}
+
+func (s fakeJobStore) Save(j jobs.Job, r jobs.Record) error {
+	s[j] = r
+	return nil
+}
+
+func TestJobRecords(t *testing.T) {
+	config = newFakeConfig()
+	config.InstallDrivers = 1
+	config.Intervals = defaultIntervals()
+	config.Jitter = map[jobs.Job]time.Duration{jobs.List: 10 * time.Minute}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	s := fakeJobStore{
+		jobs.Driver: {LastRun: now.Add(-time.Hour), LastSuccess: now.Add(-48 * time.Hour)},
+	}
+	rs := jobRecords(s, now)
+	if len(rs) != len(jobs.Jobs) {
+		t.Fatalf("jobRecords() returned %d records, want %d", len(rs), len(jobs.Jobs))
+	}
+	for _, r := range rs {
+		switch r.Job {
+		case jobs.Driver:
+			if r.LastRun == nil || r.NextRun == nil || !r.NextRun.Equal(now.Add(71*time.Hour)) {
+				t.Errorf("jobRecords() Driver = %+v, want next run at %v", r, now.Add(71*time.Hour))
+			}
+		case jobs.List:
+			if r.Jitter != "10m0s" || r.NextRun == nil || !r.NextRun.Equal(now) {
+				t.Errorf("jobRecords() List = %+v, want 10m0s jitter and next run at %v", r, now)
+			}
+		case jobs.Virus:
+			if r.Enabled || r.Error == "" || r.NextRun != nil {
+				t.Errorf("jobRecords() Virus = %+v, want disabled with an error", r)
+			}
+		}
+	}
+}
+
+func TestWriteStatusCSV(t *testing.T) {
+	config = newFakeConfig()
+	config.Intervals = defaultIntervals()
+	var b bytes.Buffer
+	if err := writeStatus(&b, formatCSV, fakeJobStore{}, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("writeStatus(csv) returned unexpected error: %v", err)
+	}
+	want := "job,enabled,interval,splay,jitter,last_run,last_success,next_run,error"
+	if got, _, _ := strings.Cut(b.String(), "\n"); got != want {
+		t.Errorf("writeStatus(csv) header = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"golang.org/x/net/context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"flag"
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/cablib"
+	"github.com/google/cabbie/updates"
+	"github.com/google/deck"
+	"github.com/google/subcommands"
+	"github.com/google/glazier/go/helpers"
+)
+
+// Available flags
+type uninstallCmd struct {
+	kbs       string
+	updateIDs string
+	// overrideBlackout uninstalls, and reboots, even during a blackout period.
+	overrideBlackout bool
+}
+
+// uninstallResult is the outcome of uninstalling a single update.
+type uninstallResult struct {
+	Title          string
+	UpdateID       string
+	KBArticleIDs   []string
+	ResultCode     int
+	HResult        string
+	RebootRequired bool
+	Err            error
+}
+
+func (uninstallCmd) Name() string     { return "uninstall" }
+func (uninstallCmd) Synopsis() string { return "Uninstall installed updates." }
+func (uninstallCmd) Usage() string {
+	return fmt.Sprintf("%s uninstall [--kbs=\"<KBNumber>\" | --update_ids=\"<UpdateID>\"] [--override_blackout]\n", filepath.Base(os.Args[0]))
+}
+
+func (c *uninstallCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.kbs, "kbs", "", "Comma separated string of KB numbers in the form of 1234567.")
+	f.StringVar(&c.updateIDs, "update_ids", "", "Comma separated string of UpdateIDs.")
+	f.BoolVar(&c.overrideBlackout, "override_blackout", false, "Uninstall, and reboot if required, even during a blackout period.")
+}
+
+func (c uninstallCmd) Execute(_ context.Context, flags *flag.FlagSet, _ ...any) subcommands.ExitStatus {
+	if (c.kbs == "") == (c.updateIDs == "") {
+		fmt.Println("Exactly one of --kbs or --update_ids must be set.")
+		fmt.Printf("%s\nUsage: %s\n", c.Synopsis(), c.Usage())
+		return subcommands.ExitUsageError
+	}
+	if p, ok := activeBlackout(time.Now()); ok && !c.overrideBlackout {
+		fmt.Printf("Blackout period %s is in effect; pass --override_blackout to uninstall anyway.\n", p)
+		return subcommands.ExitFailure
+	}
+
+	results, err := uninstallUpdates(NewKBSet(c.kbs), helpers.StringToSlice(c.updateIDs), c.overrideBlackout)
+	if err != nil {
+		fmt.Printf("Failed to uninstall updates: %v\n", err)
+		deck.ErrorfA("Failed to uninstall updates: %v", err).With(eventID(cablib.EvtErrUninstallFailure)).Go()
+		return subcommands.ExitFailure
+	}
+	if len(results) == 0 {
+		fmt.Println("No matching installed updates found.")
+		return subcommands.ExitFailure
+	}
+
+	rc := subcommands.ExitSuccess
+	reboot := false
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("Failed to uninstall %s: %v\n", r.Title, r.Err)
+			rc = subcommands.ExitFailure
+			continue
+		}
+		fmt.Printf("Uninstalled %s\nResultCode: %d\nHResult Code: %s\nReboot Required: %t\n\n", r.Title, r.ResultCode, r.HResult, r.RebootRequired)
+		if r.ResultCode != agent.Succeeded {
+			rc = subcommands.ExitFailure
+		}
+		reboot = reboot || r.RebootRequired
+	}
+	if rc == subcommands.ExitSuccess && reboot {
+		fmt.Println("Please reboot to finalize the update removal.")
+		return 6
+	}
+	return rc
+}
+
+// uninstallUpdates removes the installed updates matching any of the KBs or
+// UpdateIDs, and schedules a reboot if any removal requires one. The reboot
+// is held during a blackout period unless overrideBlackout is set.
+func uninstallUpdates(kbs KBSet, updateIDs []string, overrideBlackout bool) ([]uninstallResult, error) {
+	a, err := newAgent()
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+
+	uc, err := a.Search("IsInstalled=1")
+	if err != nil {
+		return nil, fmt.Errorf("error encountered when attempting to query for installed updates: %v", err)
+	}
+
+	var results []uninstallResult
+	var kbsToReboot []string
+	for _, u := range uc {
+		if !uninstallMatch(u, kbs, updateIDs) {
+			continue
+		}
+		r := uninstallResult{Title: u.Title, UpdateID: u.Identity.UpdateID, KBArticleIDs: u.KBArticleIDs}
+		if !u.IsUninstallable {
+			r.Err = fmt.Errorf("update is not uninstallable")
+			deck.ErrorfA("Update is not uninstallable:\n%s", u.Title).With(eventID(cablib.EvtErrUninstallFailure)).Go()
+			results = append(results, r)
+			continue
+		}
+
+		deck.InfofA("Uninstalling Update:\n%v", u).With(eventID(cablib.EvtUninstall)).Go()
+		rsp, err := a.Uninstall(u)
+		if err != nil {
+			r.Err = err
+			deck.ErrorfA("Failed to uninstall update:\n%s\n%v", u.Title, err).With(eventID(cablib.EvtErrUninstallFailure)).Go()
+			results = append(results, r)
+			continue
+		}
+		r.ResultCode, r.HResult, r.RebootRequired = rsp.ResultCode, rsp.HResult, rsp.RebootRequired
+		results = append(results, r)
+
+		if rsp.ResultCode != agent.Succeeded {
+			deck.ErrorfA("Failed to uninstall update:\n%s\nReturnCode: %d\nHResult Code: %s", u.Title, rsp.ResultCode, rsp.HResult).With(eventID(cablib.EvtErrUninstallFailure)).Go()
+			continue
+		}
+		deck.InfofA("Successfully uninstalled update:\n%s\nHResult Code: %s\nReboot Required: %t", u.Title, rsp.HResult, rsp.RebootRequired).With(eventID(cablib.EvtUninstall)).Go()
+		if rsp.RebootRequired {
+			kbsToReboot = append(kbsToReboot, u.KBArticleIDs...)
+		}
+	}
+
+	if len(kbsToReboot) > 0 {
+		scheduleReboot(kbsToReboot, false, overrideBlackout)
+	}
+	return results, nil
+}
+
+// rollback removes the updates, given as KBs or UpdateIDs, that are installed
+// and uninstallable, then hides them so they are not installed again. Updates
+// that fail to uninstall are left visible, so that the next enforcement
+// retries them, and are reported in the error. The reboot the removal
+// requires is held during a blackout period unless overrideBlackout is set.
+func rollback(ids []string, overrideBlackout bool) error {
+	kbs := NewKBSetFromSlice(ids)
+	results, err := uninstallUpdates(kbs, ids, overrideBlackout)
+	if err != nil {
+		return err
+	}
+	failed := make(map[string]bool)
+	var errs []string
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			errs = append(errs, fmt.Sprintf("%s: %v", r.Title, r.Err))
+		case r.ResultCode != agent.Succeeded:
+			errs = append(errs, fmt.Sprintf("%s: ResultCode %d, HResult %s", r.Title, r.ResultCode, r.HResult))
+		default:
+			continue
+		}
+		failed[r.UpdateID] = true
+	}
+	if err := hideRolledBack(kbs, ids, failed); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to uninstall %d update(s):\n%s", len(errs), strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// hideRolledBack hides the updates matching any of the KBs or UpdateIDs,
+// except those whose UpdateID is in failed.
+func hideRolledBack(kbs KBSet, updateIDs []string, failed map[string]bool) error {
+	a, err := newAgent()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	uc, err := a.Search("IsHidden=0 and IsInstalled=0 or IsHidden=0 and IsInstalled=1")
+	if err != nil {
+		return err
+	}
+	for _, u := range uc {
+		if failed[u.Identity.UpdateID] || !uninstallMatch(u, kbs, updateIDs) {
+			continue
+		}
+		deck.InfofA("Hiding rolled back update:\n%s", u.Title).With(eventID(cablib.EvtHide)).Go()
+		if err := a.Hide(u); err != nil {
+			deck.ErrorfA("Failed to hide update %s:\n %s", u.Title, err).With(eventID(cablib.EvtErrHide)).Go()
+		}
+	}
+	return nil
+}
+
+func uninstallMatch(u *updates.Update, kbs KBSet, updateIDs []string) bool {
+	if kbs.Size() > 0 && kbs.Search(u.KBArticleIDs) {
+		return true
+	}
+	for _, id := range updateIDs {
+		if id == u.Identity.UpdateID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/agent"
+	"github.com/google/cabbie/blackout"
+	"github.com/google/cabbie/enforcement"
+	"github.com/google/cabbie/updates"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUninstallUpdates(t *testing.T) {
+	f := &agent.Fake{Updates: []*updates.Update{
+		{Title: "Bad CU", Identity: updates.Identity{UpdateID: "bad"}, KBArticleIDs: []string{"5031356"}, IsInstalled: true, IsUninstallable: true},
+		{Title: "Servicing Stack", Identity: updates.Identity{UpdateID: "ssu"}, KBArticleIDs: []string{"5031357"}, IsInstalled: true},
+		{Title: "Other", Identity: updates.Identity{UpdateID: "other"}, KBArticleIDs: []string{"5031358"}, IsInstalled: true, IsUninstallable: true},
+		{Title: "Available", Identity: updates.Identity{UpdateID: "available"}, KBArticleIDs: []string{"5031356"}, IsUninstallable: true},
+	}}
+	defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+	newAgent = func() (agent.Agent, error) { return f, nil }
+
+	results, err := uninstallUpdates(NewKBSet("KB5031356,KB5031357"), nil, false)
+	if err != nil {
+		t.Fatalf("uninstallUpdates() returned unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"bad"}, f.Uninstalled); diff != "" {
+		t.Errorf("uninstallUpdates() uninstalled unexpected updates (-want +got):\n%s", diff)
+	}
+	if len(results) != 2 || results[0].ResultCode != agent.Succeeded || results[1].Err == nil {
+		t.Errorf("uninstallUpdates() = %+v, want success for Bad CU and an error for Servicing Stack", results)
+	}
+
+	if _, err := uninstallUpdates(NewKBSet(""), []string{"other"}, false); err != nil {
+		t.Fatalf("uninstallUpdates() returned unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"bad", "other"}, f.Uninstalled); diff != "" {
+		t.Errorf("uninstallUpdates() uninstalled unexpected updates (-want +got):\n%s", diff)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	f := &agent.Fake{Updates: []*updates.Update{
+		{Title: "Bad CU", Identity: updates.Identity{UpdateID: "bad"}, KBArticleIDs: []string{"5031356"}, IsInstalled: true, IsUninstallable: true},
+		{Title: "Bad Driver", Identity: updates.Identity{UpdateID: "driver"}, IsInstalled: true, IsUninstallable: true},
+		{Title: "Good CU", Identity: updates.Identity{UpdateID: "good"}, KBArticleIDs: []string{"5031357"}, IsInstalled: true, IsUninstallable: true},
+	}}
+	defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+	newAgent = func() (agent.Agent, error) { return f, nil }
+
+	if err := rollback([]string{"5031356", "driver"}, false); err != nil {
+		t.Fatalf("rollback() returned unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"bad", "driver"}, f.Uninstalled); diff != "" {
+		t.Errorf("rollback() uninstalled unexpected updates (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"bad", "driver"}, f.Hidden); diff != "" {
+		t.Errorf("rollback() hid unexpected updates (-want +got):\n%s", diff)
+	}
+}
+
+func TestRollbackFailure(t *testing.T) {
+	f := &agent.Fake{
+		Updates: []*updates.Update{
+			{Title: "Bad CU", Identity: updates.Identity{UpdateID: "bad"}, KBArticleIDs: []string{"5031356"}, IsInstalled: true, IsUninstallable: true},
+			{Title: "Stuck CU", Identity: updates.Identity{UpdateID: "stuck"}, KBArticleIDs: []string{"5031357"}, IsInstalled: true, IsUninstallable: true},
+			{Title: "Servicing Stack", Identity: updates.Identity{UpdateID: "ssu"}, KBArticleIDs: []string{"5031358"}, IsInstalled: true},
+		},
+		Outcomes: map[string]agent.Outcome{"stuck": {Uninstall: agent.Result{ResultCode: agent.Failed}}},
+	}
+	defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+	newAgent = func() (agent.Agent, error) { return f, nil }
+
+	if err := rollback([]string{"5031356", "5031357", "5031358"}, false); err == nil {
+		t.Errorf("rollback() with failed uninstalls returned nil, want error")
+	}
+	// Only the update that was uninstalled is hidden.
+	if diff := cmp.Diff([]string{"bad"}, f.Hidden); diff != "" {
+		t.Errorf("rollback() hid unexpected updates (-want +got):\n%s", diff)
+	}
+}
+
+func TestEnforceRollbackBlackout(t *testing.T) {
+	now := time.Now()
+	defer func(f func() (blackout.Calendar, []blackout.FileError, error)) { loadBlackout = f }(loadBlackout)
+	loadBlackout = func() (blackout.Calendar, []blackout.FileError, error) {
+		return blackout.Calendar{{Name: "Freeze", Start: now.Add(-time.Hour), End: now.Add(time.Hour)}}, nil, nil
+	}
+	defer func(f func() (agent.Agent, error)) { newAgent = f }(newAgent)
+	tests := []struct {
+		desc string
+		e    enforcement.Enforcements
+		want []string
+	}{
+		{"held", enforcement.Enforcements{Uninstall: []string{"5031356"}}, nil},
+		{"override-blackout", enforcement.Enforcements{Uninstall: []string{"5031356", "5031357"}, OverrideBlackout: []string{"5031357"}}, []string{"emergency"}},
+	}
+	for _, tt := range tests {
+		f := &agent.Fake{Updates: []*updates.Update{
+			{Title: "Bad CU", Identity: updates.Identity{UpdateID: "bad"}, KBArticleIDs: []string{"5031356"}, IsInstalled: true, IsUninstallable: true},
+			{Title: "Emergency Rollback", Identity: updates.Identity{UpdateID: "emergency"}, KBArticleIDs: []string{"5031357"}, IsInstalled: true, IsUninstallable: true},
+		}}
+		newAgent = func() (agent.Agent, error) { return f, nil }
+		if err := enforceRollback(tt.e, now); err != nil {
+			t.Errorf("%s: enforceRollback() returned unexpected error: %v", tt.desc, err)
+		}
+		if diff := cmp.Diff(tt.want, f.Uninstalled); diff != "" {
+			t.Errorf("%s: enforceRollback() uninstalled unexpected updates (-want +got):\n%s", tt.desc, diff)
+		}
+	}
+}
@@ -16,21 +16,25 @@
 package updatehistory
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/google/cabbie/errors"
 	"github.com/google/cabbie/updates"
 	"github.com/go-ole/go-ole"
 )
 
-// History represents an ordered read-only list of IUpdateHistoryEntry interfaces.
-type History struct {
-	IUpdateHistoryEntryCollection *ole.IDispatch
-	Entries                       []*Entry
-}
+// PageSize is the number of entries read from the device at a time.
+const PageSize = 50
 
 // Entry represents the recorded history of an update.
 type Entry struct {
-	Item                *ole.IDispatch
+	// Item is the IUpdateHistoryEntry that New reads the entry from. Get and
+	// Iterator release it once the entry is read, so it is always nil in the
+	// entries they return.
+	Item                *ole.IDispatch `json:"-"`
 	Operation           int
 	ResultCode          int
 	HResult             int
@@ -46,3 +50,170 @@ type Entry struct {
 	SupportURL          string
 	Categories          []updates.Category
 }
+
+var kbPattern = regexp.MustCompile(`(?i)\bKB(\d+)`)
+
+func (e *Entry) String() string {
+	return fmt.Sprintf("Title: %s\n"+
+		"UpdateIdentity: %+v\n"+
+		"Operation: %s\n"+
+		"Result: %s\n"+
+		"HResult: %s\n"+
+		"ClientApplicationID: %s\n"+
+		"SupportURL: %s\n"+
+		"Categories: %+v\n"+
+		"Date: %s", e.Title, e.UpdateIdentity, e.OperationName(), e.ResultName(), e.HResultCode(),
+		e.ClientApplicationID, e.SupportURL, e.Categories, e.Date)
+}
+
+// Operation values of an update history entry.
+const (
+	Installation   = 1
+	Uninstallation = 2
+)
+
+var (
+	operationNames = map[int]string{Installation: "install", Uninstallation: "uninstall"}
+	// resultNames follow the OperationResultCode enumeration.
+	resultNames = map[int]string{
+		0: "not-started",
+		1: "in-progress",
+		2: "succeeded",
+		3: "succeeded-with-errors",
+		4: "failed",
+		5: "aborted",
+	}
+)
+
+// OperationName returns the operation of the entry as install or uninstall.
+func (e *Entry) OperationName() string {
+	if n, ok := operationNames[e.Operation]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// ResultName returns the result code of the entry in words, e.g. failed.
+func (e *Entry) ResultName() string {
+	if n, ok := resultNames[e.ResultCode]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// HResultCode returns the HResult of the entry as an UpdateError.
+func (e *Entry) HResultCode() errors.UpdateError {
+	return errors.UpdateError(uint32(e.HResult))
+}
+
+// KBArticleIDs returns the KB article IDs named in the entry title, without
+// the KB prefix. History entries carry no KB list of their own.
+func (e *Entry) KBArticleIDs() []string {
+	var kbs []string
+	for _, m := range kbPattern.FindAllStringSubmatch(e.Title, -1) {
+		kbs = append(kbs, m[1])
+	}
+	return kbs
+}
+
+// Filter selects update history entries. Unset fields match every entry.
+type Filter struct {
+	// Since and Until bound the entry date, inclusively.
+	Since time.Time
+	Until time.Time
+	// KBs match entries naming any of the KB article IDs, with or without the KB prefix.
+	KBs        []string
+	UpdateIDs  []string
+	Categories []string
+	// Operation is install or uninstall.
+	Operation string
+	// Result is a result name such as failed or succeeded.
+	Result string
+	// Limit stops the search after this many matching entries, if positive.
+	Limit int
+}
+
+// Validate returns an error if the filter cannot match any entry.
+func (f Filter) Validate() error {
+	if f.Operation != "" && !inNames(f.Operation, operationNames) {
+		return fmt.Errorf("unknown operation %q", f.Operation)
+	}
+	if f.Result != "" && !inNames(f.Result, resultNames) {
+		return fmt.Errorf("unknown result %q", f.Result)
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", f.Limit)
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && f.Until.Before(f.Since) {
+		return fmt.Errorf("until (%s) is before since (%s)", f.Until, f.Since)
+	}
+	return nil
+}
+
+// Match returns true if the entry satisfies every set field of the filter.
+func (f Filter) Match(e *Entry) bool {
+	if !f.Since.IsZero() && e.Date.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Date.After(f.Until) {
+		return false
+	}
+	if f.Operation != "" && e.OperationName() != f.Operation {
+		return false
+	}
+	if f.Result != "" && e.ResultName() != f.Result {
+		return false
+	}
+	if len(f.UpdateIDs) > 0 && !contains(f.UpdateIDs, e.UpdateIdentity.UpdateID) {
+		return false
+	}
+	if len(f.KBs) > 0 {
+		found := false
+		for _, kb := range e.KBArticleIDs() {
+			for _, want := range f.KBs {
+				if strings.TrimPrefix(strings.ToUpper(want), "KB") == kb {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Categories) > 0 {
+		found := false
+		for _, c := range e.Categories {
+			if contains(f.Categories, c.Name) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Past returns true if e is older than Since. History is read most recent
+// first, so no later entry can match either.
+func (f Filter) Past(e *Entry) bool {
+	return !f.Since.IsZero() && e.Date.Before(f.Since)
+}
+
+func inNames(name string, names map[int]string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, e string) bool {
+	for _, v := range s {
+		if strings.EqualFold(v, e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updatehistory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cabbie/errors"
+	"github.com/google/cabbie/updates"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNames(t *testing.T) {
+	for _, tt := range []struct {
+		e             Entry
+		wantOperation string
+		wantResult    string
+		wantHResult   errors.UpdateError
+	}{
+		{Entry{Operation: Installation, ResultCode: 2}, "install", "succeeded", errors.SUCCESS},
+		{Entry{Operation: Uninstallation, ResultCode: 4, HResult: -2145124330}, "uninstall", "failed", errors.WU_E_INSTALL_NOT_ALLOWED},
+		{Entry{Operation: 9, ResultCode: 9}, "unknown", "unknown", errors.SUCCESS},
+	} {
+		if got := tt.e.OperationName(); got != tt.wantOperation {
+			t.Errorf("OperationName(%d) = %q, want %q", tt.e.Operation, got, tt.wantOperation)
+		}
+		if got := tt.e.ResultName(); got != tt.wantResult {
+			t.Errorf("ResultName(%d) = %q, want %q", tt.e.ResultCode, got, tt.wantResult)
+		}
+		if got := tt.e.HResultCode(); got != tt.wantHResult {
+			t.Errorf("HResultCode(%d) = %v, want %v", tt.e.HResult, got, tt.wantHResult)
+		}
+	}
+}
+
+func TestKBArticleIDs(t *testing.T) {
+	e := &Entry{Title: "2026-10 Cumulative Update for Windows 11 (KB5031356) and kb5031357"}
+	if diff := cmp.Diff([]string{"5031356", "5031357"}, e.KBArticleIDs()); diff != "" {
+		t.Errorf("KBArticleIDs(%q) returned unexpected diff (-want +got):\n%s", e.Title, diff)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	day := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	e := &Entry{
+		Operation:      Installation,
+		ResultCode:     4,
+		Date:           day,
+		Title:          "Security Update (KB5031356)",
+		UpdateIdentity: updates.Identity{UpdateID: "sec"},
+		Categories:     []updates.Category{{Name: "Security Updates"}},
+	}
+	for _, tt := range []struct {
+		desc string
+		f    Filter
+		want bool
+	}{
+		{"empty", Filter{}, true},
+		{"since", Filter{Since: day.Add(-time.Hour)}, true},
+		{"since after", Filter{Since: day.Add(time.Hour)}, false},
+		{"until", Filter{Until: day}, true},
+		{"until before", Filter{Until: day.Add(-time.Hour)}, false},
+		{"kb", Filter{KBs: []string{"KB5031356"}}, true},
+		{"kb without prefix", Filter{KBs: []string{"1234", "5031356"}}, true},
+		{"other kb", Filter{KBs: []string{"1234"}}, false},
+		{"update id", Filter{UpdateIDs: []string{"sec"}}, true},
+		{"other update id", Filter{UpdateIDs: []string{"other"}}, false},
+		{"operation", Filter{Operation: "install"}, true},
+		{"other operation", Filter{Operation: "uninstall"}, false},
+		{"result", Filter{Result: "failed"}, true},
+		{"other result", Filter{Result: "succeeded"}, false},
+		{"category", Filter{Categories: []string{"security updates"}}, true},
+		{"other category", Filter{Categories: []string{"Drivers"}}, false},
+		{"all", Filter{KBs: []string{"5031356"}, Operation: "install", Result: "failed", Categories: []string{"Security Updates"}}, true},
+	} {
+		if got := tt.f.Match(e); got != tt.want {
+			t.Errorf("Match(%s) = %t, want %t", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		f       Filter
+		wantErr bool
+	}{
+		{Filter{}, false},
+		{Filter{Operation: "uninstall", Result: "aborted"}, false},
+		{Filter{Operation: "remove"}, true},
+		{Filter{Result: "broken"}, true},
+		{Filter{Since: now, Until: now.Add(-time.Hour)}, true},
+		{Filter{Limit: -1}, true},
+	} {
+		if err := tt.f.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) returned error %v, want error %t", tt.f, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPast(t *testing.T) {
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		f    Filter
+		want bool
+	}{
+		{Filter{}, false},
+		{Filter{Since: day}, false},
+		{Filter{Since: day.Add(time.Second)}, true},
+		{Filter{Until: day.Add(-time.Second)}, false},
+	} {
+		if got := tt.f.Past(&Entry{Date: day}); got != tt.want {
+			t.Errorf("Past(%+v) = %t, want %t", tt.f, got, tt.want)
+		}
+	}
+}
@@ -183,69 +183,123 @@ func (e *Entry) fillStruct(m map[string]interface{}) error {
 	return nil
 }
 
-func (e *Entry) String() string {
-	return fmt.Sprintf("Title: %s\n"+
-		"UpdateIdentity: %+v\n"+
-		"ClientApplicationID: %s\n"+
-		"SupportURL: %s\n"+
-		"Categories: %+v\n"+
-		"Date: %s", e.Title, e.UpdateIdentity, e.ClientApplicationID, e.SupportURL, e.Categories, e.Date)
+// Iterator pages through the update history of the device, most recent
+// entry first, returning the entries that match a filter.
+type Iterator struct {
+	searcher *search.Searcher
+	filter   Filter
+	pageSize int
+	total    int
+	start    int
+	page     []*Entry
+	matched  int
+	entry    *Entry
+	done     bool
+	err      error
 }
 
-// Get returns a history object containing the list of update history entries.
-func Get(searchInterface *search.Searcher) (*History, error) {
+// NewIterator returns an iterator over the history entries matching f.
+func NewIterator(searchInterface *search.Searcher, f Filter) (*Iterator, error) {
 	c, err := searchInterface.GetTotalHistoryCount()
 	if err != nil {
 		return nil, err
 	}
+	return &Iterator{searcher: searchInterface, filter: f, pageSize: PageSize, total: c}, nil
+}
 
-	hc, err := searchInterface.QueryHistory(c)
-	if err != nil {
-		return nil, err
+// Next advances to the next matching entry. It returns false once the history
+// is exhausted, the Since or Limit bound of the filter is met, or an error
+// occurs.
+func (it *Iterator) Next() bool {
+	it.entry = nil
+	for !it.done {
+		if len(it.page) == 0 {
+			if it.start >= it.total {
+				it.done = true
+				break
+			}
+			it.page, it.err = readPage(it.searcher, it.start, it.pageSize)
+			if it.err != nil {
+				it.done = true
+				break
+			}
+			it.start += it.pageSize
+			continue
+		}
+		e := it.page[0]
+		it.page = it.page[1:]
+		if it.filter.Past(e) {
+			it.done = true
+			break
+		}
+		if !it.filter.Match(e) {
+			continue
+		}
+		it.matched++
+		if it.filter.Limit > 0 && it.matched >= it.filter.Limit {
+			it.done = true
+		}
+		it.entry = e
+		return true
 	}
+	return false
+}
 
-	h := History{IUpdateHistoryEntryCollection: hc}
+// Entry returns the entry found by the last call to Next.
+func (it *Iterator) Entry() *Entry {
+	return it.entry
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
 
-	count, err := h.Count()
+// readPage reads count entries starting at start. Each entry and the page
+// collection are released before returning.
+func readPage(searchInterface *search.Searcher, start, count int) ([]*Entry, error) {
+	hc, err := searchInterface.QueryHistory(start, count)
 	if err != nil {
-		h.Close()
 		return nil, err
 	}
+	defer hc.Release()
 
-	for i := 0; i < count; i++ {
-		item, err := oleutil.GetProperty(h.IUpdateHistoryEntryCollection, "item", i)
+	n, err := oleutil.GetProperty(hc, "Count")
+	if err != nil {
+		return nil, fmt.Errorf("error getting history collection count, %v", err)
+	}
+	c := int(n.Val)
+	_ = n.Clear()
+
+	var entries []*Entry
+	for i := 0; i < c; i++ {
+		item, err := oleutil.GetProperty(hc, "item", i)
 		if err != nil {
-			h.Close()
 			return nil, err
 		}
-		itemd := item.ToIDispatch()
-		uh, err := New(itemd)
+		uh, err := New(item.ToIDispatch())
+		_ = item.Clear()
 		if err != nil {
-			itemd.Release()
-			h.Close()
 			return nil, fmt.Errorf("errors in update enumeration: %v", err)
 		}
+		uh.Item = nil
 		// Weed out random invalid entries that show up for some reason.
 		if uh.Operation != 0 {
-			h.Entries = append(h.Entries, uh)
+			entries = append(entries, uh)
 		}
-		_ = item.Clear()
 	}
-
-	return &h, nil
+	return entries, nil
 }
 
-// Count gets the number of updates in an IUpdateHistoryEntryCollection.
-func (hc *History) Count() (int, error) {
-	count, err := oleutil.GetProperty(hc.IUpdateHistoryEntryCollection, "Count")
+// Get returns the history entries matching f, most recent first.
+func Get(searchInterface *search.Searcher, f Filter) ([]*Entry, error) {
+	it, err := NewIterator(searchInterface, f)
 	if err != nil {
-		return 0, fmt.Errorf("error getting history collection count, %v", err)
+		return nil, err
 	}
-	defer count.Clear()
-	return int(count.Val), nil
-}
-
-// Close turns down any open update sessions.
-func (hc *History) Close() {
-	hc.IUpdateHistoryEntryCollection.Release()
+	var entries []*Entry
+	for it.Next() {
+		entries = append(entries, it.Entry())
+	}
+	return entries, it.Err()
 }
@@ -16,11 +16,18 @@
 package updates
 
 import (
+	"fmt"
+	"regexp"
 	"time"
 
+	"github.com/google/cabbie/cablib"
 	"github.com/go-ole/go-ole"
 )
 
+// previewRe matches the titles and categories of preview releases, such as
+// the optional "C" and "D" week cumulative updates.
+var previewRe = regexp.MustCompile(`(?i)\bpreview\b`)
+
 // Identity represents the unique identifier of an update.
 type Identity struct {
 	RevisionNumber int
@@ -37,7 +44,7 @@ type Category struct {
 // Update contains the update interface and properties that are available to an update.
 // See https://docs.microsoft.com/en-us/windows/win32/api/wuapi/nn-wuapi-iupdate for details.
 type Update struct {
-	Item  *ole.IDispatch
+	Item  *ole.IDispatch `json:"-"`
 	Title string
 
 	AutoDownload             int
@@ -83,3 +90,48 @@ type Update struct {
 	DriverProvider      string
 	DriverVerDate       time.Time
 }
+
+func (up *Update) String() string {
+	return fmt.Sprintf("Title: %s\n"+
+		"Categories: %+v\n"+
+		"MsrcSeverity: %s\n"+
+		"EulaAccepted: %t\n"+
+		"KBArticleIDs: %v", up.Title, up.Categories, up.MsrcSeverity, up.EulaAccepted, up.KBArticleIDs)
+}
+
+func (up *Update) fillStruct(m map[string]interface{}) error {
+	for k, v := range m {
+		if err := cablib.SetField(up, k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InCategories determines whether or not this update is in one of the supplied categories.
+func (up *Update) InCategories(categories []string) bool {
+	if len(categories) == 0 {
+		return true
+	}
+
+	for _, v := range up.Categories {
+		if cablib.StringInSlice(v.Name, categories) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPreview determines whether or not this update is a preview release, from
+// its title or categories.
+func (up *Update) IsPreview() bool {
+	if previewRe.MatchString(up.Title) {
+		return true
+	}
+	for _, c := range up.Categories {
+		if previewRe.MatchString(c.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -46,6 +46,23 @@ func TestInCategories(t *testing.T) {
 	}
 }
 
+func TestIsPreview(t *testing.T) {
+	for _, tt := range []struct {
+		in  Update
+		out bool
+	}{
+		{Update{Title: "2026-09 Cumulative Update Preview for Windows 11 Version 24H2 for x64-based Systems (KB5065789)"}, true},
+		{Update{Title: "2026-09 Cumulative Update Preview for .NET Framework 3.5 and 4.8.1 for Windows 11 (KB5066131)"}, true},
+		{Update{Title: "Update", Categories: []Category{{Name: "Windows Insider Pre-Release"}, {Name: "Preview Updates"}}}, true},
+		{Update{Title: "2026-10 Cumulative Update for Windows 11 Version 24H2 for x64-based Systems (KB5066835)"}, false},
+		{Update{Title: "Previewer driver update"}, false},
+	} {
+		if o := tt.in.IsPreview(); o != tt.out {
+			t.Errorf("IsPreview(%q) = %v, want %v", tt.in.Title, o, tt.out)
+		}
+	}
+}
+
 func TestFillStruct(t *testing.T) {
 	data := make(map[string]interface{})
 	for _, tt := range []struct {
@@ -249,34 +249,3 @@ func toIdentity(v *ole.VARIANT) (Identity, error) {
 		UpdateID:       toString(uid),
 	}, nil
 }
-
-func (up *Update) String() string {
-	return fmt.Sprintf("Title: %s\n"+
-		"Categories: %+v\n"+
-		"MsrcSeverity: %s\n"+
-		"EulaAccepted: %t\n"+
-		"KBArticleIDs: %v", up.Title, up.Categories, up.MsrcSeverity, up.EulaAccepted, up.KBArticleIDs)
-}
-
-func (up *Update) fillStruct(m map[string]interface{}) error {
-	for k, v := range m {
-		if err := cablib.SetField(up, k, v); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-// InCategories determines whether or not this update is in one of the supplied categories.
-func (up *Update) InCategories(categories []string) bool {
-	if len(categories) == 0 {
-		return true
-	}
-
-	for _, v := range up.Categories {
-		if cablib.StringInSlice(v.Name, categories) {
-			return true
-		}
-	}
-	return false
-}